@@ -0,0 +1,107 @@
+package automation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/mqtt-home/shelly-commands/sun"
+	"github.com/philipparndt/go-logger"
+)
+
+// Controller periodically adjusts actors to the sun position
+type Controller struct {
+	cfg      config.Config
+	registry *shelly.ActorRegistry
+
+	mu     sync.Mutex
+	states map[string]*actorState
+}
+
+type actorState struct {
+	// active is set while the sun hits the facade
+	active     bool
+	lastChange time.Time
+	lastValue  int
+}
+
+func NewController(cfg config.Config, registry *shelly.ActorRegistry) *Controller {
+	return &Controller{
+		cfg:      cfg,
+		registry: registry,
+		states:   make(map[string]*actorState),
+	}
+}
+
+// Start runs the controller in the background
+func (c *Controller) Start() {
+	if !c.cfg.Automation.Enabled {
+		logger.Info("Sun automation is disabled in the configuration")
+		return
+	}
+	if c.cfg.Location == nil {
+		logger.Error("Sun automation requires a location in the configuration")
+		return
+	}
+
+	interval := time.Duration(c.cfg.Automation.Interval) * time.Second
+	logger.Info("Starting sun automation", "interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		c.update(time.Now())
+		for now := range ticker.C {
+			c.update(now)
+		}
+	}()
+}
+
+func (c *Controller) update(now time.Time) {
+	azimuth, elevation := sun.Position(now, c.cfg.Location.Latitude, c.cfg.Location.Longitude)
+	logger.Debug("Sun position", "azimuth", azimuth, "elevation", elevation)
+
+	for _, actor := range c.registry.GetAllActors() {
+		device := actor.Device()
+		if device.FacadeAzimuth == nil {
+			continue
+		}
+
+		if actor.IsBlinds() {
+			c.trackSlats(actor, device, azimuth, elevation, now)
+		} else if actor.IsRollerShutter() && device.Window != nil {
+			c.limitPenetration(actor, device, azimuth, elevation, now)
+		}
+	}
+}
+
+func (c *Controller) state(actor *shelly.ShadingActor) *actorState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.states[actor.Name]
+	if state == nil {
+		state = &actorState{}
+		c.states[actor.Name] = state
+	}
+	return state
+}
+
+// mayChange returns true if the minimum change interval since the last
+// automated change of the actor has passed
+func (c *Controller) mayChange(state *actorState, now time.Time) bool {
+	minInterval := time.Duration(c.cfg.Automation.MinChangeInterval) * time.Second
+	return state.lastChange.IsZero() || now.Sub(state.lastChange) >= minInterval
+}
+
+func (c *Controller) submit(actor *shelly.ShadingActor, state *actorState, command commands.LLCommand, value int, now time.Time) {
+	if err := actor.Submit(command); err != nil {
+		return
+	}
+	state.active = true
+	state.lastChange = now
+	state.lastValue = value
+}
@@ -0,0 +1,59 @@
+package automation
+
+import (
+	"math"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/philipparndt/go-logger"
+)
+
+// minPositionChange avoids moving the shutter for changes that are hardly visible
+const minPositionChange = 5
+
+// penetrationPosition returns the shutter position (0 = closed, 100 = open)
+// at which direct sunlight reaches at most the penetration depth into the room
+func penetrationPosition(window config.WindowConfig, profile float64) int {
+	if window.Height <= 0 {
+		return 100
+	}
+
+	// Light entering at height h reaches the floor at h / tan(profile)
+	maxHeight := window.PenetrationDepth * math.Tan(profile*math.Pi/180)
+	position := (maxHeight - window.SillHeight) / window.Height * 100
+
+	return int(math.Round(math.Max(0, math.Min(position, 100))))
+}
+
+// limitPenetration moves the roller shutter so that direct sunlight reaches
+// only the configured depth into the room while the sun hits the facade
+func (c *Controller) limitPenetration(actor *shelly.ShadingActor, device config.Device, azimuth, elevation float64, now time.Time) {
+	state := c.state(actor)
+
+	if !sunHitsFacade(device, azimuth, elevation) {
+		if state.active {
+			logger.Info("Sun left facade, stopping penetration control", "actor", actor.Name)
+			state.active = false
+		}
+		return
+	}
+
+	position := penetrationPosition(*device.Window, profileAngle(device, azimuth, elevation))
+
+	if state.active && abs(position-state.lastValue) < minPositionChange {
+		return
+	}
+	if !c.mayChange(state, now) {
+		logger.Debug("Skipping penetration control, changed recently", "actor", actor.Name, "last_change", state.lastChange)
+		return
+	}
+
+	logger.Info("Sun penetration position update", "actor", actor.Name, "azimuth", azimuth, "elevation", elevation, "position", position)
+	c.submit(actor, state, commands.LLCommand{
+		Action:   commands.LLActionSet,
+		Position: position,
+		Priority: commands.PriorityAutomation,
+	}, position, now)
+}
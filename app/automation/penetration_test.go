@@ -0,0 +1,37 @@
+package automation
+
+import (
+	"testing"
+
+	"github.com/mqtt-home/shelly-commands/config"
+)
+
+func TestPenetrationPosition(t *testing.T) {
+	door := config.WindowConfig{Height: 210, SillHeight: 0, PenetrationDepth: 100}
+
+	tests := []struct {
+		name    string
+		window  config.WindowConfig
+		profile float64
+		want    int
+	}{
+		// 100 * tan 45° = 100 of 210
+		{"medium sun", door, 45, 48},
+		// 100 * tan 60° = 173.2 of 210
+		{"high sun", door, 60, 82},
+		{"sun patch above the window", door, 75, 100},
+		// 100 * tan 30° = 57.7 is below the sill at 90
+		{"sill above the sun patch", config.WindowConfig{Height: 120, SillHeight: 90, PenetrationDepth: 100}, 30, 0},
+		// (100 * tan 45° - 60) of 120
+		{"sill below the sun patch", config.WindowConfig{Height: 120, SillHeight: 60, PenetrationDepth: 100}, 45, 33},
+		{"no window height", config.WindowConfig{PenetrationDepth: 100}, 45, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := penetrationPosition(tt.window, tt.profile); got != tt.want {
+				t.Errorf("penetrationPosition(%.0f°) = %d, want %d", tt.profile, got, tt.want)
+			}
+		})
+	}
+}
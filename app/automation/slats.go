@@ -0,0 +1,91 @@
+package automation
+
+import (
+	"math"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/philipparndt/go-logger"
+)
+
+// minSlatChange avoids moving the slats for changes that are hardly visible
+const minSlatChange = 5
+
+// sunHitsFacade returns true if the sun is above the horizon of the device
+// and in front of the facade
+func sunHitsFacade(device config.Device, azimuth, elevation float64) bool {
+	if elevation <= math.Max(0, device.HorizonElevation) {
+		return false
+	}
+	return math.Abs(angleDifference(azimuth, *device.FacadeAzimuth)) < 90
+}
+
+// angleDifference returns the signed difference between two azimuths in -180..180
+func angleDifference(a, b float64) float64 {
+	return math.Mod(a-b+540, 360) - 180
+}
+
+// profileAngle returns the sun elevation projected onto the plane
+// perpendicular to the facade in degrees
+func profileAngle(device config.Device, azimuth, elevation float64) float64 {
+	incidence := angleDifference(azimuth, *device.FacadeAzimuth) * math.Pi / 180
+	return math.Atan(math.Tan(elevation*math.Pi/180)/math.Cos(incidence)) * 180 / math.Pi
+}
+
+// cutOffSlatPosition returns the most open slat position (0 = closed,
+// 100 = horizontal) that still blocks direct sunlight at the profile angle
+func cutOffSlatPosition(blinds config.BlindsConfig, profile float64) int {
+	alpha := profile * math.Pi / 180
+	ratio := blinds.SlatSpacing / blinds.SlatWidth * math.Cos(alpha)
+
+	// Slat angle from horizontal at which a ray passing the upper slat just
+	// hits the lower one: spacing * cos(alpha) = width * sin(alpha + beta)
+	beta := 90.0
+	if ratio < 1 {
+		beta = (math.Asin(ratio) - alpha) * 180 / math.Pi
+	}
+	beta = math.Max(0, math.Min(beta, 90))
+
+	return int(math.Round(100 * (1 - beta/90)))
+}
+
+// trackSlats drives the blinds into tilt with the cut-off slat angle while
+// the sun hits the facade
+func (c *Controller) trackSlats(actor *shelly.ShadingActor, device config.Device, azimuth, elevation float64, now time.Time) {
+	state := c.state(actor)
+
+	if !sunHitsFacade(device, azimuth, elevation) {
+		if state.active {
+			logger.Info("Sun left facade, stopping slat tracking", "actor", actor.Name)
+			state.active = false
+		}
+		return
+	}
+
+	slat := cutOffSlatPosition(device.BlindsConfig, profileAngle(device, azimuth, elevation))
+
+	if state.active && abs(slat-state.lastValue) < minSlatChange {
+		return
+	}
+	if !c.mayChange(state, now) {
+		logger.Debug("Skipping slat tracking, changed recently", "actor", actor.Name, "last_change", state.lastChange)
+		return
+	}
+
+	logger.Info("Sun tracking slat update", "actor", actor.Name, "azimuth", azimuth, "elevation", elevation, "slat", slat)
+	c.submit(actor, state, commands.LLCommand{
+		Action:   commands.LLActionTilt,
+		Position: device.BlindsConfig.ShadingPosition,
+		Slat:     &slat,
+		Priority: commands.PriorityAutomation,
+	}, slat, now)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
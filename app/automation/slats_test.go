@@ -0,0 +1,76 @@
+package automation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mqtt-home/shelly-commands/config"
+)
+
+func TestCutOffSlatPosition(t *testing.T) {
+	defaults := config.BlindsConfig{SlatWidth: 80, SlatSpacing: 70}
+
+	tests := []struct {
+		name    string
+		blinds  config.BlindsConfig
+		profile float64
+		want    int
+	}{
+		// asin(70/80) = 61.0° from horizontal
+		{"horizontal sun", defaults, 0, 32},
+		// asin(70/80 * cos 30°) - 30° = 19.3°
+		{"medium sun", defaults, 30, 79},
+		// asin(70/80 * cos 60°) - 60° is negative, horizontal slats block the sun
+		{"high sun", defaults, 60, 100},
+		// Slats narrower than the spacing must close completely for low sun
+		{"narrow slats", config.BlindsConfig{SlatWidth: 60, SlatSpacing: 70}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cutOffSlatPosition(tt.blinds, tt.profile); got != tt.want {
+				t.Errorf("cutOffSlatPosition(%.0f°) = %d, want %d", tt.profile, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSunHitsFacade(t *testing.T) {
+	south := 180.0
+	device := config.Device{FacadeAzimuth: &south, HorizonElevation: 10}
+
+	tests := []struct {
+		name      string
+		azimuth   float64
+		elevation float64
+		want      bool
+	}{
+		{"in front", 180, 30, true},
+		{"oblique", 100, 30, true},
+		{"behind", 0, 30, false},
+		{"parallel", 90, 30, false},
+		{"below horizon", 180, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sunHitsFacade(device, tt.azimuth, tt.elevation); got != tt.want {
+				t.Errorf("sunHitsFacade(%.0f, %.0f) = %t, want %t", tt.azimuth, tt.elevation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileAngle(t *testing.T) {
+	south := 180.0
+	device := config.Device{FacadeAzimuth: &south}
+
+	// In front of the facade, the profile angle is the elevation
+	if got := profileAngle(device, 180, 30); math.Abs(got-30) > 0.01 {
+		t.Errorf("profileAngle(180, 30) = %.2f, want 30", got)
+	}
+	// atan(tan 30° / cos 60°) = 49.1°
+	if got := profileAngle(device, 240, 30); math.Abs(got-49.1) > 0.1 {
+		t.Errorf("profileAngle(240, 30) = %.2f, want 49.1", got)
+	}
+}
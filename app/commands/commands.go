@@ -24,14 +24,36 @@ const (
 	ActionCloseAndOpenBlinds ActionType = "closeandopenblinds"
 	ActionTilt               ActionType = "tilt"
 	ActionSlat               ActionType = "slat"
+	ActionStop               ActionType = "stop"
+	ActionStep               ActionType = "step"
+	ActionSlatStep           ActionType = "slatstep"
 )
 
 type Action struct {
 	Action   ActionType `json:"action"`
 	Position int        `json:"position"`
+	Delta    int        `json:"delta"`
+	Slat     *int       `json:"slat,omitempty"`
+	// ID is an optional correlation ID echoed in the command results
+	ID string `json:"id,omitempty"`
+	// ResponseTopic optionally overrides the topic the command results are published to
+	ResponseTopic string `json:"responseTopic,omitempty"`
+	// Force moves the actor even if frost protection is active
+	Force bool `json:"force,omitempty"`
+	// Priority is one of default, automation, manual, safety
+	Priority Priority `json:"priority,omitempty"`
+	// Hold in seconds overrides the configured hold duration of the priority
+	Hold *int `json:"hold,omitempty"`
 }
 
+// Parse parses a JSON command. Plain-text payloads (UP, DOWN, STOP) and bare
+// positions (42) are accepted as well.
 func Parse(data []byte) (LLCommand, error) {
+	text := trimPayload(data)
+	if !strings.HasPrefix(text, "{") {
+		return parseText(text)
+	}
+
 	var command Action
 	err := json.Unmarshal(data, &command)
 
@@ -43,7 +65,13 @@ func Parse(data []byte) (LLCommand, error) {
 }
 
 func (c *Action) validate() (LLCommand, error) {
-	llc := LLCommand{}
+	llc := LLCommand{
+		ID:            c.ID,
+		ResponseTopic: c.ResponseTopic,
+		Force:         c.Force,
+		Priority:      c.Priority,
+		Hold:          c.Hold,
+	}
 	switch strings.ToLower(string(c.Action)) {
 	case string(ActionClose):
 		llc.Action = LLActionSet
@@ -56,18 +84,36 @@ func (c *Action) validate() (LLCommand, error) {
 	case "":
 		llc.Action = LLActionSet
 		llc.Position = c.Position
+		if c.Slat != nil {
+			// Position and slat in one payload: move, then set the slat
+			llc.Action = LLActionTilt
+			llc.Slat = c.Slat
+		}
 	case string(ActionCloseAndOpenBlinds):
 		llc.Action = LLActionTilt
 		llc.Position = 0
 	case string(ActionTilt):
 		llc.Action = LLActionTilt
 		llc.Position = c.Position
+		llc.Slat = c.Slat
 	case string(ActionSlat):
 		llc.Action = LLActionSlat
 		llc.Position = c.Position
+	case string(ActionStop):
+		llc.Action = LLActionStop
+	case string(ActionStep):
+		llc.Action = LLActionStep
+		llc.Delta = c.Delta
+	case string(ActionSlatStep):
+		llc.Action = LLActionSlatStep
+		llc.Delta = c.Delta
 	default:
 		return llc, fmt.Errorf("invalid action")
 	}
 
+	if c.Hold != nil && *c.Hold < 0 {
+		return llc, fmt.Errorf("hold must not be negative")
+	}
+
 	return llc, nil
 }
@@ -6,9 +6,53 @@ const (
 	LLActionSet  LLAction = "set"
 	LLActionTilt LLAction = "tilt"
 	LLActionSlat LLAction = "slat"
+	LLActionStop LLAction = "stop"
+	// LLActionStep moves the position relative to the current position by Delta
+	LLActionStep LLAction = "step"
+	// LLActionSlatStep moves the slat relative to the current slat position by Delta
+	LLActionSlatStep LLAction = "slatstep"
 )
 
 type LLCommand struct {
 	Action   LLAction
 	Position int
+	Delta    int
+	// Slat overrides the configured tilt percentage for LLActionTilt
+	Slat *int
+
+	ID            string
+	ResponseTopic string
+
+	// Force overrides locks that allow it, e.g. frost protection
+	Force bool
+	// Priority of the command, see EffectivePriority
+	Priority Priority
+	// Hold in seconds overrides the configured hold duration of the priority
+	Hold *int
+}
+
+// WantsResult returns true if the sender asked for command results
+func (c LLCommand) WantsResult() bool {
+	return c.ID != "" || c.ResponseTopic != ""
+}
+
+// WithDefaultPriority sets the priority unless the sender has set one
+func (c LLCommand) WithDefaultPriority(priority Priority) LLCommand {
+	if c.Priority == 0 {
+		c.Priority = priority
+	}
+	return c
+}
+
+// EffectivePriority returns the priority or the default priority if none is set
+func (c LLCommand) EffectivePriority() Priority {
+	if c.Priority == 0 {
+		return PriorityDefault
+	}
+	return c.Priority
+}
+
+// IsAutomated returns true for commands issued by schedules and automations
+func (c LLCommand) IsAutomated() bool {
+	return c.EffectivePriority() == PriorityAutomation
 }
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Priority decides which commands may interrupt a hold on an actor.
+// The zero value means the sender did not set a priority.
+type Priority int
+
+const (
+	PriorityDefault Priority = iota + 1
+	PriorityAutomation
+	PriorityManual
+	PrioritySafety
+)
+
+var priorityNames = map[Priority]string{
+	PriorityDefault:    "default",
+	PriorityAutomation: "automation",
+	PriorityManual:     "manual",
+	PrioritySafety:     "safety",
+}
+
+func ParsePriority(name string) (Priority, error) {
+	for priority, priorityName := range priorityNames {
+		if strings.EqualFold(name, priorityName) {
+			return priority, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q", name)
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return priorityNames[PriorityDefault]
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	priority, err := ParsePriority(s)
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Plain-text payloads as sent by openHAB rollershutter items and simple buttons
+const (
+	textUp   = "up"
+	textDown = "down"
+	textStop = "stop"
+)
+
+// parseText parses a plain-text or scalar payload such as UP, DOWN, STOP or 42
+func parseText(text string) (LLCommand, error) {
+	switch strings.ToLower(text) {
+	case textUp, string(ActionOpen):
+		return LLCommand{Action: LLActionSet, Position: 100}, nil
+	case textDown, string(ActionClose):
+		return LLCommand{Action: LLActionSet, Position: 0}, nil
+	case textStop:
+		return LLCommand{Action: LLActionStop}, nil
+	}
+
+	position, err := parseScalar(text)
+	if err != nil {
+		return LLCommand{}, fmt.Errorf("invalid command %q", text)
+	}
+
+	return LLCommand{Action: LLActionSet, Position: position}, nil
+}
+
+// ParsePosition parses the payload of a <name>/position/set topic.
+// Besides a scalar position, UP, DOWN and STOP are accepted.
+func ParsePosition(data []byte) (LLCommand, error) {
+	return parseText(trimPayload(data))
+}
+
+// ParseSlat parses the scalar payload of a <name>/slat/set topic
+func ParseSlat(data []byte) (LLCommand, error) {
+	position, err := parseScalar(trimPayload(data))
+	if err != nil {
+		return LLCommand{}, fmt.Errorf("invalid slat position %q", string(data))
+	}
+
+	return LLCommand{Action: LLActionSlat, Position: position}, nil
+}
+
+// parseScalar parses an integer or decimal number and rounds it
+func parseScalar(text string) (int, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid number %q", text)
+	}
+
+	return int(math.Round(value)), nil
+}
+
+// trimPayload removes surrounding whitespace and quotes
+func trimPayload(data []byte) string {
+	return strings.Trim(strings.TrimSpace(string(data)), `"`)
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/mqtt-home/shelly-commands/commands"
 	"github.com/philipparndt/go-logger"
 	"github.com/philipparndt/mqtt-gateway/config"
 )
@@ -12,10 +15,122 @@ import (
 var cfg Config
 
 type Config struct {
-	MQTT     config.MQTTConfig `json:"mqtt"`
-	Shelly   Shelly            `json:"shelly"`
-	Web      WebConfig         `json:"web"`
-	LogLevel string            `json:"loglevel,omitempty"`
+	MQTT          config.MQTTConfig   `json:"mqtt"`
+	Shelly        Shelly              `json:"shelly"`
+	Web           WebConfig           `json:"web"`
+	HomeAssistant HomeAssistantConfig `json:"homeassistant"`
+	Automation    AutomationConfig    `json:"automation"`
+	Override      OverrideConfig      `json:"override"`
+	Priorities    PriorityConfig      `json:"priorities"`
+	Scenes        map[string]Scene    `json:"scenes,omitempty"`
+	Schedules     []Schedule          `json:"schedules,omitempty"`
+	Protection    []ProtectionRule    `json:"protection,omitempty"`
+	Frost         *FrostConfig        `json:"frost,omitempty"`
+	Safety        []SafetyTrigger     `json:"safety,omitempty"`
+	// TimeZone is the IANA time zone used for schedules, e.g. Europe/Berlin.
+	// Defaults to the local time zone.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Location is used to compute sun events and the sun position locally
+	Location *Location `json:"location,omitempty"`
+	LogLevel string    `json:"loglevel,omitempty"`
+	// StateFile persists runtime state like maintenance locks, defaults to
+	// state.json next to the configuration file
+	StateFile string `json:"stateFile,omitempty"`
+}
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Schedule runs a command or scene at the times given by a cron expression
+type Schedule struct {
+	ID string `json:"id"`
+	// Either Cron or Sun must be set
+	Cron string       `json:"cron,omitempty"`
+	Sun  *SunSchedule `json:"sun,omitempty"`
+	// TimeZone overrides the global time zone for this schedule
+	TimeZone string `json:"timeZone,omitempty"`
+	// Target is an actor name, group:<group-id> or scene:<scene-id>
+	Target string `json:"target"`
+	// Command uses the same format as the <name>/set topic; not needed for scenes
+	Command json.RawMessage `json:"command,omitempty"`
+	Enabled *bool           `json:"enabled,omitempty"`
+}
+
+// SunSchedule triggers relative to a sun event at the configured location
+type SunSchedule struct {
+	// Event is one of sunrise, sunset, civilDawn, civilDusk
+	Event string `json:"event"`
+	// Offset in minutes, negative values trigger before the event
+	Offset int `json:"offset,omitempty"`
+	// NotBefore and NotAfter clamp the trigger time (HH:MM local time)
+	NotBefore string `json:"notBefore,omitempty"`
+	NotAfter  string `json:"notAfter,omitempty"`
+}
+
+// ProtectionRule drives actors to a safe position and locks them while a
+// sensor value (e.g. wind speed or rain) exceeds a threshold
+type ProtectionRule struct {
+	ID    string `json:"id"`
+	Topic string `json:"topic"`
+	// Property is the dotted path of the value in a JSON payload, e.g. "wind.speed".
+	// Without a property the payload must be a number, true/false or ON/OFF.
+	Property  string  `json:"property,omitempty"`
+	Threshold float64 `json:"threshold"`
+	// Hysteresis below the threshold the value must fall to clear the condition
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+	// TriggerDelay in seconds the threshold must be exceeded before triggering
+	TriggerDelay int `json:"triggerDelay,omitempty"`
+	// ReleaseDelay in seconds the condition must be cleared before unlocking
+	ReleaseDelay int `json:"releaseDelay,omitempty"`
+	// Targets are actor names or group:<group-id>
+	Targets []string `json:"targets"`
+	// Command drives the actors to the safe position, defaults to open
+	Command json.RawMessage `json:"command,omitempty"`
+}
+
+// FrostConfig blocks movement while the outdoor temperature is below the threshold
+type FrostConfig struct {
+	Topic string `json:"topic"`
+	// Property is the dotted path of the temperature in a JSON payload
+	Property string `json:"property,omitempty"`
+	// Threshold in °C below which the actors are locked
+	Threshold float64 `json:"threshold"`
+	// Hysteresis above the threshold the temperature must rise to unlock
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+	// Targets are actor names or group:<group-id>, defaults to all actors
+	Targets []string `json:"targets,omitempty"`
+}
+
+// SafetyTrigger opens the target actors immediately when an alarm (e.g. a
+// smoke detector) is published and latches them until it is cleared
+type SafetyTrigger struct {
+	ID    string `json:"id"`
+	Topic string `json:"topic"`
+	// Property is the dotted path of the value in a JSON payload, e.g. "smoke"
+	Property string `json:"property,omitempty"`
+	// Payload is the alarm value (case-insensitive), defaults to true
+	Payload string `json:"payload,omitempty"`
+	// Targets are actor names or group:<group-id>, defaults to all actors
+	Targets []string `json:"targets,omitempty"`
+}
+
+func (s *Schedule) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// Scene is a named set of commands spanning multiple actors or groups
+type Scene struct {
+	Name     string         `json:"name,omitempty"`
+	Commands []SceneCommand `json:"commands"`
+}
+
+type SceneCommand struct {
+	// Target is an actor name or group:<group-id>
+	Target string `json:"target"`
+	// Command uses the same format as the <name>/set topic
+	Command json.RawMessage `json:"command"`
 }
 
 type WebConfig struct {
@@ -23,6 +138,37 @@ type WebConfig struct {
 	Port    int  `json:"port"`
 }
 
+type AutomationConfig struct {
+	Enabled bool `json:"enabled"`
+	// Interval in seconds between two sun position updates
+	Interval int `json:"interval,omitempty"`
+	// MinChangeInterval in seconds between two automated changes of the same actor
+	MinChangeInterval int `json:"minChangeInterval,omitempty"`
+}
+
+// OverrideConfig makes schedules and the sun automation back off after an
+// actor has been moved manually, e.g. with the wall switch
+type OverrideConfig struct {
+	// Sources are the Shelly status sources considered manual (case-insensitive)
+	Sources []string `json:"sources,omitempty"`
+	// Duration in seconds of the override window
+	Duration int `json:"duration,omitempty"`
+}
+
+// PriorityConfig configures how long a command holds the actor against
+// commands with a lower priority
+type PriorityConfig struct {
+	// Hold in seconds per priority (default, automation, manual, safety)
+	Hold map[string]int `json:"hold,omitempty"`
+}
+
+type HomeAssistantConfig struct {
+	Enabled         bool   `json:"enabled"`
+	DiscoveryPrefix string `json:"discoveryPrefix,omitempty"`
+	// Groups publishes every group as its own cover entity
+	Groups bool `json:"groups,omitempty"`
+}
+
 type DeviceType string
 
 const (
@@ -33,27 +179,103 @@ const (
 type BlindsConfig struct {
 	TiltPercentage int `json:"tiltPercentage"`
 	TiltPosition   int `json:"tiltPosition,omitempty"`
+	// SlatWidth and SlatSpacing (any unit) describe the slat geometry used to
+	// compute the cut-off angle when tracking the sun
+	SlatWidth   float64 `json:"slatWidth,omitempty"`
+	SlatSpacing float64 `json:"slatSpacing,omitempty"`
+	// ShadingPosition is the position the blinds are moved to while tracking the sun
+	ShadingPosition int `json:"shadingPosition,omitempty"`
 }
 
+type Protocol string
+
+const (
+	// ProtocolGen2 uses the MQTT string commands of Gen2 devices (default)
+	ProtocolGen2 Protocol = "gen2"
+	// ProtocolGen1 uses the roller topics of Gen1 devices like the Shelly 2.5
+	ProtocolGen1 Protocol = "gen1"
+	// ProtocolRPC sends JSON-RPC frames to Gen2 devices and reports their errors
+	ProtocolRPC Protocol = "rpc"
+	// ProtocolHTTP calls the RPC methods of Gen2 devices via HTTP only
+	ProtocolHTTP Protocol = "http"
+)
+
 type Device struct {
-	Name         string       `json:"name"`
-	TopicBase    string       `json:"topicBase"`
+	Name      string `json:"name"`
+	TopicBase string `json:"topicBase"`
+	// Protocol is gen2 (default), rpc, gen1 or http
+	Protocol Protocol `json:"protocol,omitempty"`
+	// Address is the host of the device for HTTP RPC calls (protocol rpc or
+	// http), the status is polled and commands without response via MQTT are
+	// sent via HTTP
+	Address string `json:"address,omitempty"`
+	// Channel is the id of the cover component (cover:<channel>), devices
+	// with several channels share the same topic base
+	Channel      int          `json:"channel,omitempty"`
 	DeviceType   DeviceType   `json:"deviceType,omitempty"`
 	BlindsConfig BlindsConfig `json:"blindsConfig"`
 	Rank         int          `json:"rank,omitempty"`
 	GroupIDs     []string     `json:"groupIds,omitempty"`
 	// Deprecated: Use GroupIDs instead. Kept for backward compatibility.
 	GroupID string `json:"groupId,omitempty"`
+	// FacadeAzimuth is the direction the window faces (degrees clockwise from north).
+	// Devices without a facade azimuth are not automated.
+	FacadeAzimuth *float64 `json:"facadeAzimuth,omitempty"`
+	// HorizonElevation is the elevation (degrees) below which the sun is blocked by the surroundings
+	HorizonElevation float64 `json:"horizonElevation,omitempty"`
+	// Window enables sun penetration control for roller shutters
+	Window *WindowConfig `json:"window,omitempty"`
+	// Contact prevents closing while a window or door is open
+	Contact *ContactConfig `json:"contact,omitempty"`
+}
+
+type ContactMode string
+
+const (
+	ContactModeClamp  ContactMode = "clamp"
+	ContactModeReject ContactMode = "reject"
+)
+
+// ContactConfig references a window or door contact
+type ContactConfig struct {
+	Topic string `json:"topic"`
+	// Property is the dotted path of the contact state in a JSON payload, e.g. "contact"
+	Property string `json:"property,omitempty"`
+	// ClosedPayload is the value reported while closed (case-insensitive), defaults to true
+	ClosedPayload string `json:"closedPayload,omitempty"`
+	// MinPosition is the lowest position allowed while open. Without it, only a full close is prevented.
+	MinPosition int `json:"minPosition,omitempty"`
+	// Mode is clamp (default) or reject
+	Mode ContactMode `json:"mode,omitempty"`
+	// Reapply runs the clamped or rejected command again once the contact closes
+	Reapply bool `json:"reapply,omitempty"`
+}
+
+// WindowConfig describes the window geometry in centimetres
+type WindowConfig struct {
+	Height     float64 `json:"height"`
+	SillHeight float64 `json:"sillHeight"`
+	// PenetrationDepth is how far direct sunlight may reach into the room
+	PenetrationDepth float64 `json:"penetrationDepth"`
 }
 
 func (d *Device) String() string {
-	return fmt.Sprintf("Device{name: %s; base: %s; type: %s}", d.Name, d.TopicBase, d.DeviceType)
+	return fmt.Sprintf("Device{name: %s; base: %s; channel: %d; type: %s}", d.Name, d.TopicBase, d.Channel, d.DeviceType)
 }
 
 func (d *Device) IsRollerShutter() bool {
 	return d.DeviceType == DeviceTypeRollerShutter
 }
 
+// Endpoint returns the address for devices only reachable via HTTP, the
+// topic base otherwise
+func (d *Device) Endpoint() string {
+	if d.Protocol == ProtocolHTTP {
+		return d.Address
+	}
+	return d.TopicBase
+}
+
 func (d *Device) IsBlinds() bool {
 	return d.DeviceType == DeviceTypeBlinds
 }
@@ -111,12 +333,76 @@ func LoadConfig(file string) (Config, error) {
 		cfg.LogLevel = "info"
 	}
 
+	if cfg.StateFile == "" {
+		cfg.StateFile = filepath.Join(filepath.Dir(file), "state.json")
+	}
+
+	if cfg.HomeAssistant.DiscoveryPrefix == "" {
+		cfg.HomeAssistant.DiscoveryPrefix = "homeassistant"
+	}
+
+	if cfg.Automation.Interval <= 0 {
+		cfg.Automation.Interval = 300
+	}
+	if cfg.Automation.MinChangeInterval <= 0 {
+		cfg.Automation.MinChangeInterval = 900
+	}
+
+	holds := make(map[string]int)
+	for name, hold := range cfg.Priorities.Hold {
+		priority, err := commands.ParsePriority(name)
+		if err != nil {
+			return Config{}, fmt.Errorf("priorities: %w", err)
+		}
+		if hold < 0 {
+			return Config{}, fmt.Errorf("priorities: hold of %s must not be negative", name)
+		}
+		holds[priority.String()] = hold
+	}
+	cfg.Priorities.Hold = holds
+
+	if len(cfg.Override.Sources) == 0 {
+		cfg.Override.Sources = []string{"SHC", "switch", "button"}
+	}
+	if cfg.Override.Duration <= 0 {
+		cfg.Override.Duration = 7200
+	}
+
+	for i := range cfg.Protection {
+		if cfg.Protection[i].ID == "" {
+			cfg.Protection[i].ID = fmt.Sprintf("protection-%d", i+1)
+		}
+		if len(cfg.Protection[i].Command) == 0 {
+			cfg.Protection[i].Command = json.RawMessage(`{"action":"open"}`)
+		}
+		if cfg.Protection[i].ReleaseDelay <= 0 {
+			cfg.Protection[i].ReleaseDelay = 600
+		}
+	}
+
+	for i := range cfg.Safety {
+		if cfg.Safety[i].ID == "" {
+			cfg.Safety[i].ID = fmt.Sprintf("safety-%d", i+1)
+		}
+		if cfg.Safety[i].Payload == "" {
+			cfg.Safety[i].Payload = "true"
+		}
+	}
+
 	// Set default value for OptimizeTilt if not specified in config
 	if cfg.Shelly.OptimizeTilt == nil {
 		defaultOptimizeTilt := true
 		cfg.Shelly.OptimizeTilt = &defaultOptimizeTilt
 	}
 
+	if err := validateDevices(cfg.Shelly.Devices); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.Shelly.PollingInterval <= 0 {
+		cfg.Shelly.PollingInterval = 30
+	}
+
 	// Set default device type for devices that don't have it specified
 	for i := range cfg.Shelly.Devices {
 		if cfg.Shelly.Devices[i].DeviceType == "" {
@@ -126,11 +412,71 @@ func LoadConfig(file string) (Config, error) {
 		if cfg.Shelly.Devices[i].Rank == 0 {
 			cfg.Shelly.Devices[i].Rank = 500
 		}
+		switch cfg.Shelly.Devices[i].Protocol {
+		case "":
+			cfg.Shelly.Devices[i].Protocol = ProtocolGen2
+		case ProtocolGen1:
+			if cfg.Shelly.Devices[i].IsBlinds() {
+				return Config{}, fmt.Errorf("device %s: gen1 devices do not support blinds, use deviceType rollershutter", cfg.Shelly.Devices[i].Name)
+			}
+		case ProtocolHTTP:
+			if cfg.Shelly.Devices[i].Address == "" {
+				return Config{}, fmt.Errorf("device %s: protocol http requires an address", cfg.Shelly.Devices[i].Name)
+			}
+		case ProtocolGen2, ProtocolRPC:
+		default:
+			return Config{}, fmt.Errorf("device %s: invalid protocol %q", cfg.Shelly.Devices[i].Name, cfg.Shelly.Devices[i].Protocol)
+		}
+		// Only RPC commands report whether they arrived, so only they can fall back
+		if cfg.Shelly.Devices[i].Address != "" && cfg.Shelly.Devices[i].Protocol != ProtocolRPC && cfg.Shelly.Devices[i].Protocol != ProtocolHTTP {
+			return Config{}, fmt.Errorf("device %s: address requires protocol rpc or http", cfg.Shelly.Devices[i].Name)
+		}
+		if contact := cfg.Shelly.Devices[i].Contact; contact != nil {
+			if contact.ClosedPayload == "" {
+				contact.ClosedPayload = "true"
+			}
+			if contact.Mode == "" {
+				contact.Mode = ContactModeClamp
+			}
+			if contact.Mode != ContactModeClamp && contact.Mode != ContactModeReject {
+				return Config{}, fmt.Errorf("device %s: invalid contact mode %q", cfg.Shelly.Devices[i].Name, contact.Mode)
+			}
+		}
+		// Typical venetian blinds have slats slightly wider than their spacing
+		if cfg.Shelly.Devices[i].BlindsConfig.SlatWidth <= 0 {
+			cfg.Shelly.Devices[i].BlindsConfig.SlatWidth = 80
+		}
+		if cfg.Shelly.Devices[i].BlindsConfig.SlatSpacing <= 0 {
+			cfg.Shelly.Devices[i].BlindsConfig.SlatSpacing = 70
+		}
 	}
 
 	return cfg, nil
 }
 
+// validateDevices rejects devices with the same name or the same cover component
+func validateDevices(devices []Device) error {
+	names := make(map[string]bool)
+	components := make(map[string]string)
+	for _, device := range devices {
+		name := strings.ToLower(device.Name)
+		if names[name] {
+			return fmt.Errorf("duplicate device name %s", device.Name)
+		}
+		names[name] = true
+
+		if device.Channel < 0 {
+			return fmt.Errorf("device %s: invalid channel %d", device.Name, device.Channel)
+		}
+		component := fmt.Sprintf("%s/cover:%d", device.Endpoint(), device.Channel)
+		if other, ok := components[component]; ok {
+			return fmt.Errorf("devices %s and %s use the same channel %d of %s", other, device.Name, device.Channel, device.Endpoint())
+		}
+		components[component] = device.Name
+	}
+	return nil
+}
+
 //
 //func (c *Shelly) GetBySN(sn string) *Device {
 //	for i := range c.Devices {
@@ -0,0 +1,174 @@
+package homeassistant
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/mqtt-home/shelly-commands/version"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+// see:
+// https://www.home-assistant.io/integrations/cover.mqtt/
+// https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+
+const idPrefix = "shelly_commands_"
+
+var invalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+type Device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SwVersion    string   `json:"sw_version,omitempty"`
+}
+
+type Cover struct {
+	Name              string `json:"name"`
+	UniqueID          string `json:"unique_id"`
+	ObjectID          string `json:"object_id"`
+	DeviceClass       string `json:"device_class"`
+	AvailabilityTopic string `json:"availability_topic"`
+	CommandTopic      string `json:"command_topic"`
+	PayloadOpen       string `json:"payload_open"`
+	PayloadClose      string `json:"payload_close"`
+	PayloadStop       string `json:"payload_stop"`
+	SetPositionTopic  string `json:"set_position_topic"`
+	Optimistic        bool   `json:"optimistic,omitempty"`
+
+	StateTopic       string `json:"state_topic,omitempty"`
+	ValueTemplate    string `json:"value_template,omitempty"`
+	PositionTopic    string `json:"position_topic,omitempty"`
+	PositionTemplate string `json:"position_template,omitempty"`
+
+	TiltCommandTopic   string `json:"tilt_command_topic,omitempty"`
+	TiltStatusTopic    string `json:"tilt_status_topic,omitempty"`
+	TiltStatusTemplate string `json:"tilt_status_template,omitempty"`
+
+	Device Device `json:"device"`
+}
+
+// PublishDiscovery publishes retained discovery documents for all actors
+// and, if enabled, for all groups
+func PublishDiscovery(cfg config.Config, registry *shelly.ActorRegistry) {
+	if !cfg.HomeAssistant.Enabled {
+		logger.Info("Home Assistant discovery is disabled in the configuration")
+		return
+	}
+
+	for _, actor := range registry.GetAllActors() {
+		publish(cfg, actorCover(cfg, actor))
+	}
+
+	if !cfg.HomeAssistant.Groups {
+		return
+	}
+
+	groups := registry.GetAllGroups()
+	groupIDs := make([]string, 0, len(groups))
+	for groupID := range groups {
+		groupIDs = append(groupIDs, groupID)
+	}
+	sort.Strings(groupIDs)
+
+	for _, groupID := range groupIDs {
+		publish(cfg, groupCover(cfg, groupID, groups[groupID]))
+	}
+}
+
+func actorCover(cfg config.Config, actor *shelly.ShadingActor) Cover {
+	base := cfg.MQTT.Topic + "/" + actor.Name
+	id := objectID(actor.Name)
+
+	cover := Cover{
+		Name:              actor.DisplayName(),
+		UniqueID:          id,
+		ObjectID:          id,
+		AvailabilityTopic: cfg.MQTT.Topic + "/bridge/state",
+		CommandTopic:      base + "/set",
+		PayloadOpen:       "OPEN",
+		PayloadClose:      "CLOSE",
+		PayloadStop:       "STOP",
+		SetPositionTopic:  base + "/position/set",
+		StateTopic:        actor.StateTopic(),
+		ValueTemplate:     "{{ value_json.state }}",
+		PositionTopic:     actor.StateTopic(),
+		PositionTemplate:  "{{ value_json.position }}",
+		Device:            device(id, actor.DisplayName(), string(actor.DeviceType)),
+	}
+
+	if actor.IsRollerShutter() {
+		cover.DeviceClass = "shutter"
+	} else {
+		cover.DeviceClass = "blind"
+		cover.TiltCommandTopic = base + "/slat/set"
+		cover.TiltStatusTopic = actor.StateTopic()
+		cover.TiltStatusTemplate = "{{ value_json.slatPosition }}"
+	}
+
+	return cover
+}
+
+// groupCover returns an optimistic cover for a group, groups have no state topic
+func groupCover(cfg config.Config, groupID string, actors []*shelly.ShadingActor) Cover {
+	base := cfg.MQTT.Topic + "/group:" + groupID
+	id := objectID("group_" + groupID)
+
+	cover := Cover{
+		Name:              groupID,
+		UniqueID:          id,
+		ObjectID:          id,
+		DeviceClass:       "shutter",
+		AvailabilityTopic: cfg.MQTT.Topic + "/bridge/state",
+		CommandTopic:      base + "/set",
+		PayloadOpen:       "OPEN",
+		PayloadClose:      "CLOSE",
+		PayloadStop:       "STOP",
+		SetPositionTopic:  base + "/position/set",
+		Optimistic:        true,
+		Device:            device(id, groupID, "group"),
+	}
+
+	// Offer tilt if the group contains blinds
+	for _, actor := range actors {
+		if actor.IsBlinds() {
+			cover.DeviceClass = "blind"
+			cover.TiltCommandTopic = base + "/slat/set"
+			break
+		}
+	}
+
+	return cover
+}
+
+func objectID(name string) string {
+	return idPrefix + invalidIDChars.ReplaceAllString(strings.ToLower(name), "_")
+}
+
+func publish(cfg config.Config, cover Cover) {
+	data, err := json.Marshal(cover)
+	if err != nil {
+		logger.Error("Failed to marshal Home Assistant discovery document", "object_id", cover.ObjectID, "error", err)
+		return
+	}
+
+	topic := cfg.HomeAssistant.DiscoveryPrefix + "/cover/" + cover.ObjectID + "/config"
+	logger.Debug("Publishing Home Assistant discovery", "topic", topic)
+	mqtt.PublishAbsolute(topic, string(data), true)
+}
+
+func device(identifier string, name string, model string) Device {
+	return Device{
+		Identifiers:  []string{identifier},
+		Name:         name,
+		Manufacturer: "Shelly",
+		Model:        model,
+		SwVersion:    version.Version,
+	}
+}
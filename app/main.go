@@ -13,9 +13,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/mqtt-home/shelly-commands/automation"
 	"github.com/mqtt-home/shelly-commands/commands"
 	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/homeassistant"
+	"github.com/mqtt-home/shelly-commands/maintenance"
 	"github.com/mqtt-home/shelly-commands/monitor"
+	"github.com/mqtt-home/shelly-commands/protection"
+	"github.com/mqtt-home/shelly-commands/scene"
+	"github.com/mqtt-home/shelly-commands/schedule"
 	"github.com/mqtt-home/shelly-commands/shelly"
 	"github.com/mqtt-home/shelly-commands/version"
 	"github.com/mqtt-home/shelly-commands/web"
@@ -32,21 +38,31 @@ func startActors(cfg config.Shelly) {
 }
 
 func startActor(device *config.Device, wg *sync.WaitGroup) *shelly.ShadingActor {
-	logger.Info("Initializing actor", "name", device.Name, "topic_base", device.TopicBase)
+	logger.Info("Initializing actor", "name", device.Name, "topic_base", device.TopicBase, "channel", device.Channel)
 	actor := shelly.NewShadingActor(*device)
-	err := actor.Start()
+	err := registry.AddActor(actor)
+	if err != nil {
+		panic(err)
+	}
+	err = actor.Start()
 	if err != nil {
 		panic(err)
 	}
-	registry.AddActor(actor)
 	return actor
 }
 
 func subscribeToCommands(cfg config.Config, actors *shelly.ActorRegistry) {
 	prefix := cfg.MQTT.Topic + "/"
-	postfix := "/set"
 
 	// Subscribe to all commands (both individual and group)
+	subscribeToCommandTopic(prefix, "/set", commands.Parse, actors)
+
+	// Per-aspect topics taking a scalar value
+	subscribeToCommandTopic(prefix, "/position/set", commands.ParsePosition, actors)
+	subscribeToCommandTopic(prefix, "/slat/set", commands.ParseSlat, actors)
+}
+
+func subscribeToCommandTopic(prefix string, postfix string, parse func([]byte) (commands.LLCommand, error), actors *shelly.ActorRegistry) {
 	logger.Info("Subscribing to MQTT commands", "pattern", prefix+"+"+postfix)
 
 	mqtt.Subscribe(prefix+"+"+postfix, func(topic string, payload []byte) {
@@ -54,60 +70,48 @@ func subscribeToCommands(cfg config.Config, actors *shelly.ActorRegistry) {
 
 		targetName := topic[len(prefix) : len(topic)-len(postfix)]
 
-		command, err := commands.Parse(payload)
+		// Scenes are activated by any payload on the plain command topic
+		if strings.HasPrefix(targetName, scene.Prefix) {
+			if postfix != "/set" {
+				logger.Error("Scenes only support the set topic", "topic", topic)
+				return
+			}
+			sceneID := strings.TrimPrefix(targetName, scene.Prefix)
+			if err := scenes.Activate(sceneID, commands.PriorityManual); err != nil {
+				logger.Error("Failed to activate scene", "topic", topic, "scene", sceneID, "error", err)
+			}
+			return
+		}
+
+		command, err := parse(payload)
 		if err != nil {
 			logger.Error("Failed to parse command", "topic", topic, "payload", string(payload), "error", err)
 			return
 		}
 
-		// Check if this is a group command
-		if strings.HasPrefix(targetName, "group:") {
-			// Handle group command
-			groupID := targetName[6:] // Remove "group:" prefix
-			groupActors := actors.GetActorsByGroup(groupID)
+		dispatchCommand(targetName, command.WithDefaultPriority(commands.PriorityManual), actors)
+	})
+}
 
-			if len(groupActors) == 0 {
-				logger.Error("No actors found for group", "topic", topic, "group_id", groupID)
-				return
-			}
+// dispatchCommand queues the command on the actor or all actors of a group
+func dispatchCommand(targetName string, command commands.LLCommand, actors *shelly.ActorRegistry) {
+	targetActors, err := actors.Resolve(targetName)
+	if err != nil {
+		logger.Error("Unknown command target", "target", targetName, "error", err)
+		shelly.PublishResult(targetName, command, shelly.ResultFailed, err.Error())
+		return
+	}
 
-			logger.Info("Processing group command", "group", groupID, "actor_count", len(groupActors), "action", command.Action, "position", command.Position)
-
-			// Run command on all actors in the group
-			for _, actor := range groupActors {
-				go func(a *shelly.ShadingActor) {
-					defer func() {
-						if r := recover(); r != nil {
-							logger.Error("Panic in group command processing", "actor", a.Name, "group", groupID, "panic", r)
-						}
-					}()
-					a.Apply(command)
-				}(actor)
-			}
-		} else {
-			// Handle individual actor command
-			actor := actors.GetActor(targetName)
-			if actor == nil {
-				logger.Error("Unknown actor in command", "topic", topic, "actor_name", targetName)
-				return
-			}
+	logger.Info("Processing command", "target", targetName, "actor_count", len(targetActors), "action", command.Action, "position", command.Position, "priority", command.EffectivePriority())
 
-			logger.Info("Processing command", "actor", targetName, "action", command.Action, "position", command.Position)
-
-			// Run command in separate goroutine to avoid blocking MQTT processing
-			go func() {
-				defer func() {
-					if r := recover(); r != nil {
-						logger.Error("Panic in command processing", "actor", targetName, "panic", r)
-					}
-				}()
-				actor.Apply(command)
-			}()
-		}
-	})
+	// Queue command on the actors' executors to avoid blocking MQTT processing
+	for _, actor := range targetActors {
+		actor.Submit(command)
+	}
 }
 
 var registry = shelly.NewActorRegistry()
+var scenes *scene.Manager
 
 func initPprof() {
 	go func() {
@@ -157,14 +161,52 @@ func main() {
 	mqtt.Start(cfg.MQTT, "shelly_mqtt")
 
 	startActors(cfg.Shelly)
+
+	scenes, err = scene.NewManager(cfg.Scenes, registry)
+	if err != nil {
+		logger.Error("Failed to load scenes", "error", err)
+		return
+	}
+
+	locks := maintenance.NewManager(cfg, registry)
+	if err := locks.Restore(); err != nil {
+		logger.Error("Failed to restore maintenance locks", "error", err)
+	}
+	locks.Start()
+
+	protections, err := protection.NewManager(cfg.Protection, registry)
+	if err != nil {
+		logger.Error("Failed to load protection rules", "error", err)
+		return
+	}
+	protections.Start()
+	protection.NewFrostGuard(cfg.Frost, registry).Start()
+
+	emergency, err := protection.NewEmergency(cfg, registry)
+	if err != nil {
+		logger.Error("Failed to load safety triggers", "error", err)
+		return
+	}
+	emergency.Start()
+
 	subscribeToCommands(cfg, registry)
+	homeassistant.PublishDiscovery(cfg, registry)
+
+	scheduler, err := schedule.NewScheduler(cfg, registry, scenes)
+	if err != nil {
+		logger.Error("Failed to load schedules", "error", err)
+		return
+	}
+	scheduler.Start()
+
+	automation.NewController(cfg, registry).Start()
 
 	// Start web server
 	if !cfg.Web.Enabled {
 		logger.Info("Web interface is disabled in the configuration")
 	} else {
 		logger.Info("Web interface enabled, starting web server")
-		webServer := web.NewWebServer(registry)
+		webServer := web.NewWebServer(registry, scenes, scheduler, emergency, locks)
 		go func() {
 			err := webServer.Start(cfg.Web.Port)
 			if err != nil {
@@ -0,0 +1,212 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+const LockKey = "maintenance"
+
+// state is the content of the state file
+type state struct {
+	Maintenance map[string]shelly.Lock `json:"maintenance"`
+}
+
+// Request is the payload of the lock topic and the REST API
+type Request struct {
+	Locked *bool  `json:"locked,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Manager locks actors for maintenance, e.g. while a window is cleaned.
+// The locks are persisted in the state file.
+type Manager struct {
+	topic    string
+	file     string
+	registry *shelly.ActorRegistry
+
+	mu sync.Mutex
+}
+
+func NewManager(cfg config.Config, registry *shelly.ActorRegistry) *Manager {
+	return &Manager{
+		topic:    cfg.MQTT.Topic,
+		file:     cfg.StateFile,
+		registry: registry,
+	}
+}
+
+// Restore locks the actors that were locked before the restart
+func (m *Manager) Restore() error {
+	data, err := os.ReadFile(m.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var persisted state
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return fmt.Errorf("invalid state file %s: %w", m.file, err)
+	}
+
+	for name, lock := range persisted.Maintenance {
+		actor := m.registry.GetActor(name)
+		if actor == nil {
+			logger.Warn("Dropping maintenance lock of unknown actor", "actor", name)
+			continue
+		}
+		lock.Key = LockKey
+		lock.Safety = true
+		actor.AddLock(lock)
+		logger.Info("Restored maintenance lock", "actor", actor.Name, "reason", lock.Reason)
+	}
+	return nil
+}
+
+// Start subscribes to <topic>/<name>/lock
+func (m *Manager) Start() {
+	prefix := m.topic + "/"
+	postfix := "/lock"
+
+	logger.Info("Subscribing to maintenance locks", "pattern", prefix+"+"+postfix)
+	mqtt.Subscribe(prefix+"+"+postfix, func(topic string, payload []byte) {
+		target := topic[len(prefix) : len(topic)-len(postfix)]
+
+		request, err := ParseRequest(payload)
+		if err != nil {
+			logger.Error("Failed to parse maintenance lock", "topic", topic, "payload", string(payload), "error", err)
+			return
+		}
+
+		// Stopping the actors publishes to MQTT, do not block message processing
+		go func() {
+			var err error
+			if *request.Locked {
+				_, err = m.Lock(target, request.Reason)
+			} else {
+				_, err = m.Unlock(target)
+			}
+			if err != nil {
+				logger.Error("Failed to update maintenance lock", "target", target, "error", err)
+			}
+		}()
+	})
+}
+
+// ParseRequest parses a JSON request or a plain-text payload like ON or OFF
+func ParseRequest(payload []byte) (Request, error) {
+	text := strings.TrimSpace(string(payload))
+	if strings.HasPrefix(text, "{") {
+		var request Request
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return Request{}, err
+		}
+		if request.Locked == nil {
+			return Request{}, fmt.Errorf("missing locked")
+		}
+		return request, nil
+	}
+
+	var locked bool
+	switch strings.ToLower(text) {
+	case "on", "true", "1", "lock", "locked":
+		locked = true
+	case "off", "false", "0", "unlock", "unlocked":
+		locked = false
+	default:
+		return Request{}, fmt.Errorf("invalid lock payload %q", text)
+	}
+	return Request{Locked: &locked}, nil
+}
+
+// Lock locks the target (actor name or group:<group-id>), stops a running
+// command and returns the names of the locked actors
+func (m *Manager) Lock(target string, reason string) ([]string, error) {
+	actors, err := m.registry.Resolve(target)
+	if err != nil {
+		return nil, err
+	}
+
+	text := "maintenance"
+	if reason != "" {
+		text += ": " + reason
+	}
+
+	names := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		actor.LockSafety(LockKey, text)
+		actor.Halt()
+		names = append(names, actor.Name)
+	}
+
+	return names, m.save()
+}
+
+// Unlock unlocks the target and returns the names of the unlocked actors
+func (m *Manager) Unlock(target string) ([]string, error) {
+	actors, err := m.registry.Resolve(target)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		if actor.Unlock(LockKey) {
+			names = append(names, actor.Name)
+		}
+	}
+
+	return names, m.save()
+}
+
+// IsLocked returns true if the actor is locked for maintenance
+func IsLocked(actor *shelly.ShadingActor) bool {
+	for _, lock := range actor.Locks() {
+		if lock.Key == LockKey {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the maintenance locks of all actors to the state file
+func (m *Manager) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	persisted := state{Maintenance: make(map[string]shelly.Lock)}
+	for _, actor := range m.registry.GetAllActors() {
+		for _, lock := range actor.Locks() {
+			if lock.Key == LockKey {
+				persisted.Maintenance[actor.Name] = lock
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(persisted, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash does not corrupt the state
+	tmp := filepath.Join(filepath.Dir(m.file), "."+filepath.Base(m.file)+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp, m.file); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Lookup returns the value at the dotted property path of a JSON payload.
+// Payloads that are no JSON are returned as trimmed string.
+func Lookup(payload []byte, property string) (any, error) {
+	var data any
+	if err := json.Unmarshal(payload, &data); err != nil {
+		// Plain text payloads like ON
+		data = strings.TrimSpace(string(payload))
+	}
+
+	if property != "" {
+		for _, key := range strings.Split(property, ".") {
+			object, ok := data.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("property %s not found", property)
+			}
+			if data, ok = object[key]; !ok {
+				return nil, fmt.Errorf("property %s not found", property)
+			}
+		}
+	}
+	return data, nil
+}
+
+// Matches compares the value with the expected payload (case-insensitive)
+func Matches(value any, expected string) bool {
+	return strings.EqualFold(fmt.Sprint(value), expected)
+}
@@ -0,0 +1,235 @@
+package protection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/payload"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+const EmergencyPrefix = "emergency:"
+
+// emergencyTimeout is the maximum time to wait for each actor to accept the
+// open command before opening the next one
+const emergencyTimeout = 2 * time.Second
+
+type safetyTrigger struct {
+	cfg    config.SafetyTrigger
+	active bool
+	since  time.Time
+}
+
+// Emergency opens actors on an alarm and latches every other command until
+// the alarm is cleared via REST or MQTT
+type Emergency struct {
+	topic    string
+	registry *shelly.ActorRegistry
+
+	mu       sync.Mutex
+	triggers map[string]*safetyTrigger
+}
+
+// EmergencyInfo describes a safety trigger for the REST API
+type EmergencyInfo struct {
+	ID      string     `json:"id"`
+	Topic   string     `json:"topic"`
+	Targets []string   `json:"targets"`
+	Active  bool       `json:"active"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+func NewEmergency(cfg config.Config, registry *shelly.ActorRegistry) (*Emergency, error) {
+	e := &Emergency{
+		topic:    cfg.MQTT.Topic + "/emergency",
+		registry: registry,
+		triggers: make(map[string]*safetyTrigger),
+	}
+
+	for _, trigger := range cfg.Safety {
+		if trigger.Topic == "" {
+			return nil, fmt.Errorf("safety trigger %s: missing topic", trigger.ID)
+		}
+		if e.triggers[trigger.ID] != nil {
+			return nil, fmt.Errorf("duplicate safety trigger id %s", trigger.ID)
+		}
+		e.triggers[trigger.ID] = &safetyTrigger{cfg: trigger}
+	}
+
+	return e, nil
+}
+
+// Start subscribes to the alarm topics and the clear topic
+func (e *Emergency) Start() {
+	if len(e.triggers) == 0 {
+		return
+	}
+
+	for _, t := range e.triggers {
+		t := t
+		logger.Info("Subscribing to safety trigger", "trigger", t.cfg.ID, "topic", t.cfg.Topic, "payload", t.cfg.Payload)
+		mqtt.Subscribe(t.cfg.Topic, func(topic string, data []byte) {
+			value, err := payload.Lookup(data, t.cfg.Property)
+			if err != nil {
+				logger.Debug("Ignoring safety trigger message", "trigger", t.cfg.ID, "topic", topic, "error", err)
+				return
+			}
+			if !payload.Matches(value, t.cfg.Payload) {
+				return
+			}
+
+			// Do not block MQTT processing while opening the actors
+			go e.Trigger(t.cfg.ID)
+		})
+	}
+
+	mqtt.Subscribe(e.topic+"/+/clear", func(topic string, payload []byte) {
+		id := strings.TrimSuffix(strings.TrimPrefix(topic, e.topic+"/"), "/clear")
+		go func() {
+			if err := e.Clear(id); err != nil {
+				logger.Error("Failed to clear emergency", "topic", topic, "error", err)
+			}
+		}()
+	})
+}
+
+// Trigger opens all target actors in rank order and latches them
+func (e *Emergency) Trigger(id string) error {
+	e.mu.Lock()
+	t := e.triggers[id]
+	if t == nil {
+		e.mu.Unlock()
+		return fmt.Errorf("unknown safety trigger %s", id)
+	}
+	if !t.active {
+		t.active = true
+		t.since = time.Now()
+	}
+	e.mu.Unlock()
+
+	logger.Error("Emergency triggered, opening actors", "trigger", id)
+
+	reason := fmt.Sprintf("emergency %s: clear via REST or MQTT", id)
+	for _, actor := range e.actors(t) {
+		// An unresponsive device delays the next one by at most the timeout
+		ctx, cancel := context.WithTimeout(context.Background(), emergencyTimeout)
+		actor.EmergencyOpen(ctx, EmergencyPrefix+id, reason)
+		cancel()
+	}
+
+	e.publishState(t)
+	return nil
+}
+
+// Clear releases the latch of the safety trigger
+func (e *Emergency) Clear(id string) error {
+	e.mu.Lock()
+	t := e.triggers[id]
+	if t == nil {
+		e.mu.Unlock()
+		return fmt.Errorf("unknown safety trigger %s", id)
+	}
+	wasActive := t.active
+	t.active = false
+	t.since = time.Time{}
+	e.mu.Unlock()
+
+	if !wasActive {
+		return nil
+	}
+
+	logger.Info("Emergency cleared", "trigger", id)
+	for _, actor := range e.actors(t) {
+		actor.Unlock(EmergencyPrefix + id)
+	}
+
+	e.publishState(t)
+	return nil
+}
+
+// List returns all safety triggers sorted by ID
+func (e *Emergency) List() []EmergencyInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	infos := make([]EmergencyInfo, 0, len(e.triggers))
+	for _, t := range e.triggers {
+		info := EmergencyInfo{
+			ID:      t.cfg.ID,
+			Topic:   t.cfg.Topic,
+			Targets: t.cfg.Targets,
+			Active:  t.active,
+		}
+		if t.active {
+			since := t.since
+			info.Since = &since
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+// actors returns the distinct target actors sorted by rank, then by name
+func (e *Emergency) actors(t *safetyTrigger) []*shelly.ShadingActor {
+	var actors []*shelly.ShadingActor
+	if len(t.cfg.Targets) == 0 {
+		actors = e.registry.GetAllActors()
+	}
+	for _, target := range t.cfg.Targets {
+		resolved, err := e.registry.Resolve(target)
+		if err != nil {
+			logger.Error("Skipping safety trigger target", "trigger", t.cfg.ID, "target", target, "error", err)
+			continue
+		}
+		actors = append(actors, resolved...)
+	}
+
+	seen := make(map[string]bool)
+	distinct := actors[:0]
+	for _, actor := range actors {
+		if !seen[actor.Name] {
+			seen[actor.Name] = true
+			distinct = append(distinct, actor)
+		}
+	}
+
+	sort.Slice(distinct, func(i, j int) bool {
+		if distinct[i].Rank != distinct[j].Rank {
+			return distinct[i].Rank < distinct[j].Rank
+		}
+		return distinct[i].Name < distinct[j].Name
+	})
+	return distinct
+}
+
+func (e *Emergency) publishState(t *safetyTrigger) {
+	e.mu.Lock()
+	state := struct {
+		Active bool       `json:"active"`
+		Since  *time.Time `json:"since,omitempty"`
+	}{Active: t.active}
+	if t.active {
+		since := t.since
+		state.Since = &since
+	}
+	e.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		logger.Error("Failed to marshal emergency state", "trigger", t.cfg.ID, "error", err)
+		return
+	}
+	mqtt.PublishAbsolute(e.topic+"/"+t.cfg.ID, string(data), true)
+}
@@ -0,0 +1,93 @@
+package protection
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+const FrostLock = "frost"
+
+// FrostGuard locks actors while the outdoor temperature is below the
+// threshold. Automated commands are suppressed, manual commands need the
+// force flag.
+type FrostGuard struct {
+	cfg      *config.FrostConfig
+	registry *shelly.ActorRegistry
+
+	mu     sync.Mutex
+	active bool
+}
+
+func NewFrostGuard(cfg *config.FrostConfig, registry *shelly.ActorRegistry) *FrostGuard {
+	return &FrostGuard{cfg: cfg, registry: registry}
+}
+
+// Start subscribes to the temperature topic
+func (g *FrostGuard) Start() {
+	if g.cfg == nil {
+		return
+	}
+	if g.cfg.Topic == "" {
+		logger.Error("Frost protection requires a temperature topic")
+		return
+	}
+
+	logger.Info("Subscribing to outdoor temperature", "topic", g.cfg.Topic, "threshold", g.cfg.Threshold)
+	mqtt.Subscribe(g.cfg.Topic, func(topic string, payload []byte) {
+		temperature, err := parseValue(payload, g.cfg.Property)
+		if err != nil {
+			logger.Error("Failed to parse outdoor temperature", "topic", topic, "payload", string(payload), "error", err)
+			return
+		}
+		g.update(temperature)
+	})
+}
+
+func (g *FrostGuard) update(temperature float64) {
+	g.mu.Lock()
+	var lock, unlock bool
+	if !g.active && temperature < g.cfg.Threshold {
+		g.active = true
+		lock = true
+	} else if g.active && temperature >= g.cfg.Threshold+g.cfg.Hysteresis {
+		g.active = false
+		unlock = true
+	}
+	g.mu.Unlock()
+
+	if lock {
+		logger.Warn("Frost protection active", "temperature", temperature, "threshold", g.cfg.Threshold)
+		reason := fmt.Sprintf("frost protection: temperature %g°C below %g°C", temperature, g.cfg.Threshold)
+		for _, actor := range g.actors() {
+			actor.LockForceable(FrostLock, reason)
+		}
+	}
+	if unlock {
+		logger.Info("Frost protection released", "temperature", temperature)
+		for _, actor := range g.actors() {
+			actor.Unlock(FrostLock)
+		}
+	}
+}
+
+func (g *FrostGuard) actors() []*shelly.ShadingActor {
+	if len(g.cfg.Targets) == 0 {
+		return g.registry.GetAllActors()
+	}
+
+	var actors []*shelly.ShadingActor
+	for _, target := range g.cfg.Targets {
+		resolved, err := g.registry.Resolve(target)
+		if err != nil {
+			logger.Error("Skipping frost protection target", "target", target, "error", err)
+			continue
+		}
+		actors = append(actors, resolved...)
+	}
+	return actors
+}
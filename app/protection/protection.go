@@ -0,0 +1,214 @@
+package protection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/payload"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+const LockPrefix = "protection:"
+
+type rule struct {
+	cfg     config.ProtectionRule
+	command commands.LLCommand
+
+	value    float64
+	hasValue bool
+	active   bool
+	// exceededSince and clearedSince start the trigger and release delays
+	exceededSince time.Time
+	clearedSince  time.Time
+}
+
+// Manager drives actors to a safe position and locks them while a sensor
+// value exceeds the threshold of a protection rule
+type Manager struct {
+	registry *shelly.ActorRegistry
+
+	mu    sync.Mutex
+	rules []*rule
+}
+
+// NewManager parses the configured protection rules
+func NewManager(rules []config.ProtectionRule, registry *shelly.ActorRegistry) (*Manager, error) {
+	m := &Manager{registry: registry}
+
+	for _, cfg := range rules {
+		if cfg.Topic == "" {
+			return nil, fmt.Errorf("protection %s: missing topic", cfg.ID)
+		}
+		if len(cfg.Targets) == 0 {
+			return nil, fmt.Errorf("protection %s: missing targets", cfg.ID)
+		}
+
+		command, err := commands.Parse(cfg.Command)
+		if err != nil {
+			return nil, fmt.Errorf("protection %s: invalid command: %w", cfg.ID, err)
+		}
+
+		for _, target := range cfg.Targets {
+			if _, err := registry.Resolve(target); err != nil {
+				logger.Warn("Protection references unknown target", "protection", cfg.ID, "target", target, "error", err)
+			}
+		}
+
+		m.rules = append(m.rules, &rule{cfg: cfg, command: command})
+	}
+
+	return m, nil
+}
+
+// Start subscribes to the sensor topics and evaluates the delays in the background
+func (m *Manager) Start() {
+	if len(m.rules) == 0 {
+		return
+	}
+
+	for _, r := range m.rules {
+		r := r
+		logger.Info("Subscribing to protection sensor", "protection", r.cfg.ID, "topic", r.cfg.Topic, "threshold", r.cfg.Threshold)
+		mqtt.Subscribe(r.cfg.Topic, func(topic string, data []byte) {
+			value, err := parseValue(data, r.cfg.Property)
+			if err != nil {
+				logger.Error("Failed to parse protection sensor value", "protection", r.cfg.ID, "topic", topic, "payload", string(data), "error", err)
+				return
+			}
+
+			m.mu.Lock()
+			r.value = value
+			r.hasValue = true
+			m.mu.Unlock()
+
+			m.evaluate(time.Now())
+		})
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			m.evaluate(now)
+		}
+	}()
+}
+
+func (m *Manager) evaluate(now time.Time) {
+	var trigger, release []*rule
+
+	m.mu.Lock()
+	for _, r := range m.rules {
+		if !r.hasValue {
+			continue
+		}
+
+		if !r.active {
+			if r.value < r.cfg.Threshold {
+				r.exceededSince = time.Time{}
+				continue
+			}
+			if r.exceededSince.IsZero() {
+				r.exceededSince = now
+			}
+			if now.Sub(r.exceededSince) >= time.Duration(r.cfg.TriggerDelay)*time.Second {
+				r.active = true
+				r.clearedSince = time.Time{}
+				trigger = append(trigger, r)
+			}
+			continue
+		}
+
+		if r.value >= r.cfg.Threshold-r.cfg.Hysteresis {
+			r.clearedSince = time.Time{}
+			continue
+		}
+		if r.clearedSince.IsZero() {
+			r.clearedSince = now
+		}
+		if now.Sub(r.clearedSince) >= time.Duration(r.cfg.ReleaseDelay)*time.Second {
+			r.active = false
+			r.exceededSince = time.Time{}
+			release = append(release, r)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, r := range trigger {
+		m.trigger(r)
+	}
+	for _, r := range release {
+		m.release(r)
+	}
+}
+
+func (m *Manager) trigger(r *rule) {
+	m.mu.Lock()
+	value := r.value
+	m.mu.Unlock()
+
+	logger.Warn("Protection triggered", "protection", r.cfg.ID, "value", value, "threshold", r.cfg.Threshold)
+
+	reason := fmt.Sprintf("protection %s: value %g exceeds %g", r.cfg.ID, value, r.cfg.Threshold)
+	for _, actor := range m.actors(r) {
+		actor.Lock(LockPrefix+r.cfg.ID, reason)
+		actor.Enforce(r.command)
+	}
+}
+
+func (m *Manager) release(r *rule) {
+	logger.Info("Protection released", "protection", r.cfg.ID)
+
+	for _, actor := range m.actors(r) {
+		actor.Unlock(LockPrefix + r.cfg.ID)
+	}
+}
+
+func (m *Manager) actors(r *rule) []*shelly.ShadingActor {
+	var actors []*shelly.ShadingActor
+	for _, target := range r.cfg.Targets {
+		resolved, err := m.registry.Resolve(target)
+		if err != nil {
+			logger.Error("Skipping protection target", "protection", r.cfg.ID, "target", target, "error", err)
+			continue
+		}
+		actors = append(actors, resolved...)
+	}
+	return actors
+}
+
+// parseValue reads a number from the payload. Booleans and ON/OFF are mapped
+// to 1 and 0, so that a threshold of 1 triggers on rain sensors.
+func parseValue(data []byte, property string) (float64, error) {
+	value, err := payload.Lookup(data, property)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		switch strings.ToUpper(strings.TrimSpace(v)) {
+		case "ON", "TRUE":
+			return 1, nil
+		case "OFF", "FALSE":
+			return 0, nil
+		}
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, fmt.Errorf("unsupported value %v", value)
+}
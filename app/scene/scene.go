@@ -0,0 +1,120 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/philipparndt/go-logger"
+)
+
+const Prefix = "scene:"
+
+type step struct {
+	target  string
+	command commands.LLCommand
+	raw     json.RawMessage
+}
+
+type Scene struct {
+	ID    string
+	Name  string
+	steps []step
+}
+
+type Manager struct {
+	registry *shelly.ActorRegistry
+	scenes   map[string]*Scene
+}
+
+// Info describes a scene for the REST API
+type Info struct {
+	ID       string                `json:"id"`
+	Name     string                `json:"name"`
+	Commands []config.SceneCommand `json:"commands"`
+}
+
+// NewManager parses the configured scenes. Commands that cannot be parsed are
+// reported as error, so that broken scenes are noticed at startup.
+func NewManager(scenes map[string]config.Scene, registry *shelly.ActorRegistry) (*Manager, error) {
+	m := &Manager{
+		registry: registry,
+		scenes:   make(map[string]*Scene),
+	}
+
+	for id, cfg := range scenes {
+		scene := &Scene{ID: id, Name: cfg.Name}
+		if scene.Name == "" {
+			scene.Name = id
+		}
+
+		for _, sceneCommand := range cfg.Commands {
+			command, err := commands.Parse(sceneCommand.Command)
+			if err != nil {
+				return nil, fmt.Errorf("scene %s: invalid command for %s: %w", id, sceneCommand.Target, err)
+			}
+
+			if _, err := registry.Resolve(sceneCommand.Target); err != nil {
+				logger.Warn("Scene references unknown target", "scene", id, "target", sceneCommand.Target, "error", err)
+			}
+
+			scene.steps = append(scene.steps, step{target: sceneCommand.Target, command: command, raw: sceneCommand.Command})
+		}
+
+		m.scenes[id] = scene
+	}
+
+	return m, nil
+}
+
+// Activate queues the commands of the scene on all affected actors. The
+// priority applies to all commands of the scene that do not set their own.
+func (m *Manager) Activate(id string, priority commands.Priority) error {
+	scene := m.scenes[id]
+	if scene == nil {
+		return fmt.Errorf("unknown scene %s", id)
+	}
+
+	logger.Info("Activating scene", "scene", id, "commands", len(scene.steps))
+
+	for _, step := range scene.steps {
+		actors, err := m.registry.Resolve(step.target)
+		if err != nil {
+			logger.Error("Skipping scene command", "scene", id, "target", step.target, "error", err)
+			continue
+		}
+
+		command := step.command.WithDefaultPriority(priority)
+		for _, actor := range actors {
+			actor.Submit(command)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the scene with the ID or nil
+func (m *Manager) Get(id string) *Scene {
+	return m.scenes[id]
+}
+
+// List returns all scenes sorted by ID
+func (m *Manager) List() []Info {
+	infos := make([]Info, 0, len(m.scenes))
+	for _, scene := range m.scenes {
+		info := Info{ID: scene.ID, Name: scene.Name}
+		for _, step := range scene.steps {
+			info.Commands = append(info.Commands, config.SceneCommand{Target: step.target, Command: step.raw})
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos
+}
@@ -0,0 +1,146 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		after      time.Time
+		want       time.Time
+	}{
+		{
+			name:       "same day",
+			expression: "30 7 * * *",
+			after:      time.Date(2025, 1, 6, 6, 0, 0, 0, berlin),
+			want:       time.Date(2025, 1, 6, 7, 30, 0, 0, berlin),
+		},
+		{
+			name:       "not at the given time",
+			expression: "30 7 * * *",
+			after:      time.Date(2025, 1, 6, 7, 30, 0, 0, berlin),
+			want:       time.Date(2025, 1, 7, 7, 30, 0, 0, berlin),
+		},
+		{
+			name:       "weekdays skip the weekend",
+			expression: "30 7 * * mon-fri",
+			after:      time.Date(2025, 1, 3, 8, 0, 0, 0, berlin),
+			want:       time.Date(2025, 1, 6, 7, 30, 0, 0, berlin),
+		},
+		{
+			name:       "sunday as 7",
+			expression: "0 9 * * 7",
+			after:      time.Date(2025, 1, 6, 0, 0, 0, 0, berlin),
+			want:       time.Date(2025, 1, 12, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:       "day of month",
+			expression: "0 12 1 * *",
+			after:      time.Date(2025, 1, 15, 0, 0, 0, 0, berlin),
+			want:       time.Date(2025, 2, 1, 12, 0, 0, 0, berlin),
+		},
+		{
+			name:       "day of month or day of week, weekday first",
+			expression: "0 8 13 * fri",
+			after:      time.Date(2025, 6, 1, 0, 0, 0, 0, berlin),
+			want:       time.Date(2025, 6, 6, 8, 0, 0, 0, berlin),
+		},
+		{
+			name:       "day of month or day of week, day first",
+			expression: "0 8 3 * fri",
+			after:      time.Date(2025, 6, 1, 0, 0, 0, 0, berlin),
+			want:       time.Date(2025, 6, 3, 8, 0, 0, 0, berlin),
+		},
+		{
+			name:       "month names and year change",
+			expression: "0 0 1 jan *",
+			after:      time.Date(2025, 3, 1, 0, 0, 0, 0, berlin),
+			want:       time.Date(2026, 1, 1, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:       "steps",
+			expression: "*/15 * * * *",
+			after:      time.Date(2025, 1, 6, 10, 16, 0, 0, berlin),
+			want:       time.Date(2025, 1, 6, 10, 30, 0, 0, berlin),
+		},
+		{
+			name:       "leap day",
+			expression: "0 0 29 feb *",
+			after:      time.Date(2025, 1, 1, 0, 0, 0, 0, berlin),
+			want:       time.Date(2028, 2, 29, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:       "DST start skips the missing hour",
+			expression: "30 2 * * *",
+			after:      time.Date(2025, 3, 29, 3, 0, 0, 0, berlin),
+			want:       time.Date(2025, 3, 31, 2, 30, 0, 0, berlin),
+		},
+		{
+			name:       "DST start keeps later times",
+			expression: "0 7 * * *",
+			after:      time.Date(2025, 3, 30, 0, 0, 0, 0, berlin),
+			want:       time.Date(2025, 3, 30, 7, 0, 0, 0, berlin),
+		},
+		{
+			name:       "DST end runs once in the repeated hour",
+			expression: "30 2 * * *",
+			after:      time.Date(2025, 10, 26, 0, 0, 0, 0, berlin),
+			want:       time.Date(2025, 10, 26, 2, 30, 0, 0, time.FixedZone("CET", 3600)),
+		},
+		{
+			name:       "DST end continues on the next day",
+			expression: "30 2 * * *",
+			after:      time.Date(2025, 10, 26, 2, 30, 0, 0, time.FixedZone("CET", 3600)).In(berlin),
+			want:       time.Date(2025, 10, 27, 2, 30, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expression, err)
+			}
+			if got := cron.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+	} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expression)
+		}
+	}
+}
+
+func TestLoadLocationDefaultsToLocal(t *testing.T) {
+	location, err := loadLocation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location != time.Local {
+		t.Errorf("loadLocation(\"\") = %s, want local time zone", location)
+	}
+}
@@ -0,0 +1,329 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/scene"
+	"github.com/mqtt-home/shelly-commands/shelly"
+	"github.com/mqtt-home/shelly-commands/sun"
+	"github.com/philipparndt/go-logger"
+)
+
+// Trigger computes the run times of a schedule
+type Trigger interface {
+	// Next returns the first run after the given time or the zero time if there is none
+	Next(after time.Time) time.Time
+	String() string
+}
+
+type entry struct {
+	id       string
+	target   string
+	trigger  Trigger
+	location *time.Location
+	command  commands.LLCommand
+	enabled  bool
+	next     time.Time
+}
+
+type Scheduler struct {
+	mu       sync.Mutex
+	entries  []*entry
+	location *config.Location
+	timeZone *time.Location
+	registry *shelly.ActorRegistry
+	scenes   *scene.Manager
+	wakeup   chan struct{}
+}
+
+// Info describes a schedule for the REST API
+type Info struct {
+	ID       string      `json:"id"`
+	Trigger  string      `json:"trigger"`
+	TimeZone string      `json:"timeZone"`
+	Target   string      `json:"target"`
+	Enabled  bool        `json:"enabled"`
+	NextRuns []time.Time `json:"nextRuns"`
+}
+
+// loadLocation returns the time zone, the local time zone if it is empty.
+// time.LoadLocation returns UTC for an empty name.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// NewScheduler parses the configured schedules
+func NewScheduler(cfg config.Config, registry *shelly.ActorRegistry, scenes *scene.Manager) (*Scheduler, error) {
+	timeZone, err := loadLocation(cfg.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", cfg.TimeZone, err)
+	}
+
+	s := &Scheduler{
+		location: cfg.Location,
+		timeZone: timeZone,
+		registry: registry,
+		scenes:   scenes,
+		wakeup:   make(chan struct{}, 1),
+	}
+
+	ids := make(map[string]bool)
+	for i, schedule := range cfg.Schedules {
+		if schedule.ID == "" {
+			schedule.ID = fmt.Sprintf("schedule-%d", i+1)
+		}
+		if ids[schedule.ID] {
+			return nil, fmt.Errorf("duplicate schedule id %s", schedule.ID)
+		}
+		ids[schedule.ID] = true
+
+		e, err := s.newEntry(cfg, schedule)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", schedule.ID, err)
+		}
+		s.entries = append(s.entries, e)
+	}
+
+	return s, nil
+}
+
+func (s *Scheduler) newEntry(cfg config.Config, schedule config.Schedule) (*entry, error) {
+	timeZone := schedule.TimeZone
+	if timeZone == "" {
+		timeZone = cfg.TimeZone
+	}
+	location, err := loadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+	}
+
+	var trigger Trigger
+	switch {
+	case schedule.Cron != "" && schedule.Sun != nil:
+		return nil, fmt.Errorf("cron and sun are mutually exclusive")
+	case schedule.Sun != nil:
+		trigger, err = NewSunTrigger(*schedule.Sun, cfg.Location)
+	default:
+		trigger, err = ParseCron(schedule.Cron)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e := &entry{
+		id:       schedule.ID,
+		target:   schedule.Target,
+		trigger:  trigger,
+		location: location,
+		enabled:  schedule.IsEnabled(),
+	}
+
+	if strings.HasPrefix(schedule.Target, scene.Prefix) {
+		if s.scenes.Get(strings.TrimPrefix(schedule.Target, scene.Prefix)) == nil {
+			return nil, fmt.Errorf("unknown scene %s", schedule.Target)
+		}
+		return e, nil
+	}
+
+	if len(schedule.Command) == 0 {
+		return nil, fmt.Errorf("missing command for target %s", schedule.Target)
+	}
+	e.command, err = commands.Parse(schedule.Command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command: %w", err)
+	}
+	e.command = e.command.WithDefaultPriority(commands.PriorityAutomation)
+
+	if _, err := s.registry.Resolve(schedule.Target); err != nil {
+		logger.Warn("Schedule references unknown target", "schedule", schedule.ID, "target", schedule.Target, "error", err)
+	}
+
+	return e, nil
+}
+
+// Start runs the scheduler in the background
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	now := time.Now()
+	for _, e := range s.entries {
+		e.next = e.trigger.Next(now.In(e.location))
+		logger.Info("Schedule registered", "schedule", e.id, "trigger", e.trigger, "target", e.target, "enabled", e.enabled, "next", e.next)
+	}
+	s.mu.Unlock()
+
+	go s.run()
+}
+
+func (s *Scheduler) run() {
+	for {
+		// Without enabled schedules, only a wakeup ends the wait
+		var timer *time.Timer
+		var fired <-chan time.Time
+		if next := s.nextRun(); !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fired = timer.C
+		}
+
+		select {
+		case <-fired:
+			s.runDue(time.Now())
+		case <-s.wakeup:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+func (s *Scheduler) nextRun() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, e := range s.entries {
+		if !e.enabled || e.next.IsZero() {
+			continue
+		}
+		if next.IsZero() || e.next.Before(next) {
+			next = e.next
+		}
+	}
+	return next
+}
+
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []*entry
+	for _, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		if e.enabled {
+			due = append(due, e)
+		}
+		e.next = e.trigger.Next(now.In(e.location))
+	}
+	s.mu.Unlock()
+
+	for _, e := range due {
+		s.execute(e)
+	}
+}
+
+func (s *Scheduler) execute(e *entry) {
+	logger.Info("Running schedule", "schedule", e.id, "target", e.target)
+
+	if strings.HasPrefix(e.target, scene.Prefix) {
+		if err := s.scenes.Activate(strings.TrimPrefix(e.target, scene.Prefix), commands.PriorityAutomation); err != nil {
+			logger.Error("Failed to activate scene from schedule", "schedule", e.id, "error", err)
+		}
+		return
+	}
+
+	actors, err := s.registry.Resolve(e.target)
+	if err != nil {
+		logger.Error("Failed to run schedule", "schedule", e.id, "error", err)
+		return
+	}
+
+	for _, actor := range actors {
+		actor.Submit(e.command)
+	}
+}
+
+// SetEnabled enables or disables a schedule at runtime
+func (s *Scheduler) SetEnabled(id string, enabled bool) error {
+	s.mu.Lock()
+	found := false
+	for _, e := range s.entries {
+		if e.id == id {
+			e.enabled = enabled
+			e.next = e.trigger.Next(time.Now().In(e.location))
+			found = true
+		}
+	}
+	s.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("unknown schedule %s", id)
+	}
+
+	logger.Info("Schedule updated", "schedule", id, "enabled", enabled)
+
+	// Non-blocking wakeup to recompute the next run
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// List returns all schedules with their upcoming runs
+func (s *Scheduler) List(runs int) []Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	infos := make([]Info, 0, len(s.entries))
+	for _, e := range s.entries {
+		info := Info{
+			ID:       e.id,
+			Trigger:  e.trigger.String(),
+			TimeZone: e.location.String(),
+			Target:   e.target,
+			Enabled:  e.enabled,
+			NextRuns: []time.Time{},
+		}
+
+		if e.enabled {
+			t := now.In(e.location)
+			for i := 0; i < runs; i++ {
+				t = e.trigger.Next(t)
+				if t.IsZero() {
+					break
+				}
+				info.NextRuns = append(info.NextRuns, t)
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// SunInfo describes the next sun events at the configured location
+type SunInfo struct {
+	Latitude  float64                 `json:"latitude"`
+	Longitude float64                 `json:"longitude"`
+	TimeZone  string                  `json:"timeZone"`
+	Next      map[sun.Event]time.Time `json:"next"`
+}
+
+// SunEvents returns the next sun events or nil if no location is configured
+func (s *Scheduler) SunEvents(after time.Time) *SunInfo {
+	if s.location == nil {
+		return nil
+	}
+
+	info := &SunInfo{
+		Latitude:  s.location.Latitude,
+		Longitude: s.location.Longitude,
+		TimeZone:  s.timeZone.String(),
+		Next:      make(map[sun.Event]time.Time),
+	}
+	for _, event := range sun.Events {
+		if t := sun.Next(after.In(s.timeZone), s.location.Latitude, s.location.Longitude, event); !t.IsZero() {
+			info.Next[event] = t
+		}
+	}
+	return info
+}
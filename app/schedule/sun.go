@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/sun"
+)
+
+// SunTrigger triggers relative to a sun event, optionally clamped to a time window
+type SunTrigger struct {
+	event     sun.Event
+	offset    time.Duration
+	notBefore *clock
+	notAfter  *clock
+	latitude  float64
+	longitude float64
+}
+
+// clock is a time of day
+type clock struct {
+	hour, minute int
+}
+
+func parseClock(text string) (*clock, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time of day %q, expected HH:MM", text)
+	}
+	return &clock{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+func (c *clock) on(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), c.hour, c.minute, 0, 0, date.Location())
+}
+
+func (c *clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.hour, c.minute)
+}
+
+func NewSunTrigger(cfg config.SunSchedule, location *config.Location) (*SunTrigger, error) {
+	if location == nil {
+		return nil, fmt.Errorf("sun schedules require a location in the configuration")
+	}
+
+	event, err := sun.ParseEvent(cfg.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &SunTrigger{
+		event:     event,
+		offset:    time.Duration(cfg.Offset) * time.Minute,
+		latitude:  location.Latitude,
+		longitude: location.Longitude,
+	}
+
+	if t.notBefore, err = parseClock(cfg.NotBefore); err != nil {
+		return nil, err
+	}
+	if t.notAfter, err = parseClock(cfg.NotAfter); err != nil {
+		return nil, err
+	}
+	if t.notBefore != nil && t.notAfter != nil && t.notAfter.on(time.Now()).Before(t.notBefore.on(time.Now())) {
+		return nil, fmt.Errorf("notAfter %s is before notBefore %s", t.notAfter, t.notBefore)
+	}
+
+	return t, nil
+}
+
+// at returns the trigger time on the calendar date of the given time
+func (t *SunTrigger) at(date time.Time) (time.Time, bool) {
+	eventTime, ok := sun.Time(date, t.latitude, t.longitude, t.event)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	run := eventTime.Add(t.offset).Truncate(time.Minute)
+	if t.notBefore != nil && run.Before(t.notBefore.on(date)) {
+		run = t.notBefore.on(date)
+	}
+	if t.notAfter != nil && run.After(t.notAfter.on(date)) {
+		run = t.notAfter.on(date)
+	}
+	return run, true
+}
+
+func (t *SunTrigger) Next(after time.Time) time.Time {
+	for day := 0; day <= 366; day++ {
+		date := time.Date(after.Year(), after.Month(), after.Day()+day, 12, 0, 0, 0, after.Location())
+		run, ok := t.at(date)
+		if ok && run.After(after) {
+			return run
+		}
+	}
+	return time.Time{}
+}
+
+func (t *SunTrigger) String() string {
+	parts := []string{string(t.event)}
+	if t.offset > 0 {
+		parts[0] += "+" + t.offset.String()
+	} else if t.offset < 0 {
+		parts[0] += t.offset.String()
+	}
+	if t.notBefore != nil {
+		parts = append(parts, "not before "+t.notBefore.String())
+	}
+	if t.notAfter != nil {
+		parts = append(parts, "not after "+t.notAfter.String())
+	}
+	return strings.Join(parts, ", ")
+}
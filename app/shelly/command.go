@@ -1,7 +1,9 @@
 package shelly
 
 import (
-	"sync"
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/mqtt-home/shelly-commands/commands"
@@ -9,88 +11,149 @@ import (
 	"github.com/philipparndt/go-logger"
 )
 
-func (s *ShadingActor) Apply(command commands.LLCommand) {
+// positionTimeout is the maximum time to wait for the device to reach a position
+const positionTimeout = 60 * time.Second
+
+var (
+	ErrPositionTimeout = errors.New("timeout waiting for position")
+	ErrCancelled       = errors.New("command cancelled")
+)
+
+func (s *ShadingActor) Apply(ctx context.Context, command commands.LLCommand) error {
+	// Safety and maintenance locks may have been set after the command was accepted
+	if err := s.safetyLockError(); err != nil {
+		logger.Warn("Rejecting command", "actor", s.Name, "action", command.Action, "error", err)
+		return err
+	}
+
+	command, err := s.interlock(command)
+	if err != nil {
+		logger.Warn("Rejecting command", "actor", s.Name, "action", command.Action, "error", err)
+		return err
+	}
+
+	s.mu.Lock()
+	s.applying = &command
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.applying = nil
+		s.mu.Unlock()
+	}()
+
 	logger.Info("Applying command", "actor", s.Name, "action", command.Action, "position", command.Position, "device_type", s.DeviceType)
 
 	switch command.Action {
 	case commands.LLActionSet:
-		_, err := s.SetPosition(command.Position)
-		if err != nil {
-			logger.Error("Failed setting position", "actor", s.Name, "error", err)
-		} else {
+		_, err = s.SetPosition(ctx, command.Position)
+		if err == nil {
 			logger.Info("Set position command completed", "actor", s.Name, "position", command.Position)
 		}
 	case commands.LLActionTilt:
-		if s.IsRollerShutter() {
-			s.TiltRollerShutter()
+		if s.IsRollerShutter() && command.Slat != nil {
+			logger.Info("Ignoring slat for roller shutter, setting position only", "actor", s.Name)
+			_, err = s.SetPosition(ctx, command.Position)
+		} else if s.IsRollerShutter() {
+			err = s.TiltRollerShutter(ctx)
+		} else if command.Slat != nil {
+			err = s.Tilt(ctx, command.Position, *command.Slat)
 		} else {
-			s.Tilt(command.Position)
+			err = s.Tilt(ctx, command.Position, s.Config.TiltPercentage)
 		}
 	case commands.LLActionSlat:
 		if s.IsRollerShutter() {
 			logger.Info("Ignoring slat command for roller shutter", "actor", s.Name)
-			return
+			return nil
+		}
+		err = s.SlatOnly(ctx, command.Position)
+	case commands.LLActionStep:
+		err = s.Step(ctx, command.Delta)
+	case commands.LLActionSlatStep:
+		if s.IsRollerShutter() {
+			logger.Info("Ignoring slat step command for roller shutter", "actor", s.Name)
+			return nil
+		}
+		err = s.SlatStep(ctx, command.Delta)
+	case commands.LLActionStop:
+		_, err = s.Stop(ctx)
+		if err == nil {
+			logger.Info("Stop command completed", "actor", s.Name)
 		}
-		s.SlatOnly(command.Position)
+	default:
+		err = fmt.Errorf("unsupported action %q", command.Action)
+	}
+
+	if err != nil {
+		logger.Error("Command failed", "actor", s.Name, "action", command.Action, "error", err)
+		return err
 	}
 
 	logger.Debug("Command application finished", "actor", s.Name, "action", command.Action)
+	return nil
 }
 
-func (s *ShadingActor) Tilt(position int) {
-	logger.Info("Tilt command started", "actor", s.Name, "position", position)
+// Tilt moves to the position, waits for it to be reached and sets the slat
+func (s *ShadingActor) Tilt(ctx context.Context, position int, slat int) error {
+	logger.Info("Tilt command started", "actor", s.Name, "position", position, "slat", slat)
 
 	// Check if optimization is enabled and we're already in the correct position
-	if config.Get().Shelly.GetOptimizeTilt() && s.Tilted && s.TiltPosition == position {
-		logger.Info("Ignoring tilt command, already tilted correctly", "actor", s.Name, "current_position", s.TiltPosition)
-		return
+	s.mu.Lock()
+	alreadyTilted := s.Tilted && s.Position == position && s.TiltPosition == slat
+	s.mu.Unlock()
+	if config.Get().Shelly.GetOptimizeTilt() && alreadyTilted {
+		logger.Info("Ignoring tilt command, already tilted correctly", "actor", s.Name, "position", position, "slat", slat)
+		return nil
 	}
 
-	wg := sync.WaitGroup{}
-
 	logger.Debug("Setting position for tilt", "actor", s.Name, "target_position", position)
-	err := s.SetAndWaitForPosition(&wg, position, 60)
+	result, err := s.SetAndWaitForPosition(ctx, position, positionTimeout)
 	if err != nil {
-		logger.Error("Tilt failed; error setting position", "actor", s.Name, "error", err)
-		return
+		return fmt.Errorf("tilt failed; error setting position: %w", err)
 	}
 
-	logger.Debug("Waiting for position to be reached", "actor", s.Name)
-	wg.Wait()
+	switch result {
+	case WaitTimeout:
+		return fmt.Errorf("tilt to %d: %w", position, ErrPositionTimeout)
+	case WaitCancelled:
+		return fmt.Errorf("tilt to %d: %w", position, ErrCancelled)
+	}
 	logger.Debug("Position reached, setting slat position", "actor", s.Name)
 
 	// Wait between up and down for at least 500ms as specified in the motor documentation
-	time.Sleep(500 * time.Millisecond)
+	select {
+	case <-time.After(500 * time.Millisecond):
+	case <-ctx.Done():
+		return fmt.Errorf("tilt to %d: %w", position, ErrCancelled)
+	}
 
-	_, err = s.SetSlatPosition(s.Config.TiltPercentage)
+	_, err = s.SetSlatPosition(ctx, slat)
 	if err != nil {
-		logger.Error("Tilt failed; error setting tilt position", "actor", s.Name, "error", err)
-		return
+		return fmt.Errorf("tilt failed; error setting tilt position: %w", err)
 	}
 
 	// Safely update tilt state
 	s.mu.Lock()
 	s.Tilted = true
-	s.TiltPosition = position
+	s.TiltPosition = slat
 	s.mu.Unlock()
 
-	logger.Info("Tilt command completed successfully", "actor", s.Name, "position", position, "tilt_percentage", s.Config.TiltPercentage)
+	logger.Info("Tilt command completed successfully", "actor", s.Name, "position", position, "slat", slat)
+	return nil
 }
 
-func (s *ShadingActor) TiltRollerShutter() {
+func (s *ShadingActor) TiltRollerShutter(ctx context.Context) error {
 	tiltPos := s.Config.TiltPosition
 	logger.Info("Tilt roller shutter command started", "actor", s.Name, "target_position", tiltPos)
 
 	// Check if optimization is enabled and we're already in the correct position
 	if config.Get().Shelly.GetOptimizeTilt() && s.Tilted && s.Position == tiltPos {
 		logger.Info("Ignoring tilt command, already at tilt position", "actor", s.Name, "current_position", s.Position)
-		return
+		return nil
 	}
 
-	_, err := s.SetPosition(tiltPos)
+	_, err := s.SetPosition(ctx, tiltPos)
 	if err != nil {
-		logger.Error("Tilt roller shutter failed", "actor", s.Name, "error", err)
-		return
+		return fmt.Errorf("tilt roller shutter failed: %w", err)
 	}
 
 	s.mu.Lock()
@@ -99,23 +162,22 @@ func (s *ShadingActor) TiltRollerShutter() {
 	s.mu.Unlock()
 
 	logger.Info("Tilt roller shutter command completed", "actor", s.Name, "position", tiltPos)
+	return nil
 }
 
-func (s *ShadingActor) SlatOnly(position int) {
+func (s *ShadingActor) SlatOnly(ctx context.Context, position int) error {
 	logger.Info("Slat-only command started", "actor", s.Name, "slat_position", position)
 
 	if position != 0 {
-		_, err := s.SetSlatPosition(0)
+		_, err := s.SetSlatPosition(ctx, 0)
 		if err != nil {
-			logger.Error("Slat-only command failed", "actor", s.Name, "error", err)
-			return
+			return fmt.Errorf("slat-only command failed: %w", err)
 		}
 	}
 
-	_, err := s.SetSlatPosition(position)
+	_, err := s.SetSlatPosition(ctx, position)
 	if err != nil {
-		logger.Error("Slat-only command failed", "actor", s.Name, "error", err)
-		return
+		return fmt.Errorf("slat-only command failed: %w", err)
 	}
 
 	// Update the slat position but don't change the tilt state
@@ -124,4 +186,39 @@ func (s *ShadingActor) SlatOnly(position int) {
 	s.mu.Unlock()
 
 	logger.Info("Slat-only command completed successfully", "actor", s.Name, "slat_position", position)
+	return nil
+}
+
+// Step moves the position relative to the cached position, clamped to 0..100
+func (s *ShadingActor) Step(ctx context.Context, delta int) error {
+	s.mu.Lock()
+	current := s.Position
+	s.mu.Unlock()
+
+	target := clamp(current+delta, 0, 100)
+	logger.Info("Step command started", "actor", s.Name, "delta", delta, "from", current, "to", target)
+
+	_, err := s.SetPosition(ctx, target)
+	if err != nil {
+		return fmt.Errorf("step command failed: %w", err)
+	}
+
+	logger.Info("Step command completed", "actor", s.Name, "position", target)
+	return nil
+}
+
+// SlatStep moves the slat relative to the cached slat position, clamped to 0..100
+func (s *ShadingActor) SlatStep(ctx context.Context, delta int) error {
+	s.mu.Lock()
+	current := s.TiltPosition
+	s.mu.Unlock()
+
+	target := clamp(current+delta, 0, 100)
+	logger.Info("Slat step command started", "actor", s.Name, "delta", delta, "from", current, "to", target)
+
+	return s.SlatOnly(ctx, target)
+}
+
+func clamp(value, lower, upper int) int {
+	return max(lower, min(value, upper))
 }
@@ -0,0 +1,174 @@
+package shelly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/mqtt-home/shelly-commands/payload"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+var ErrContactOpen = errors.New("window contact is open")
+
+// subscribeContact tracks the window or door contact of the device
+func (s *ShadingActor) subscribeContact() {
+	contact := s.device.Contact
+	if contact == nil {
+		return
+	}
+
+	logger.Info("Subscribing to window contact", "actor", s.Name, "topic", contact.Topic)
+	mqtt.Subscribe(contact.Topic, func(topic string, data []byte) {
+		value, err := payload.Lookup(data, contact.Property)
+		if err != nil {
+			logger.Error("Failed to parse window contact", "actor", s.Name, "topic", topic, "payload", string(data), "error", err)
+			return
+		}
+		s.updateContact(!payload.Matches(value, contact.ClosedPayload))
+	})
+}
+
+func (s *ShadingActor) updateContact(open bool) {
+	s.mu.Lock()
+	changed := s.contactOpen != open
+	s.contactOpen = open
+	var pending *commands.LLCommand
+	if !open {
+		pending = s.pendingContact
+		s.pendingContact = nil
+	}
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	logger.Info("Window contact changed", "actor", s.Name, "open", open)
+	s.notifyChange()
+
+	if open {
+		// Cancelling the command in flight waits for it, do not block MQTT processing
+		go s.protect()
+	}
+
+	if pending != nil {
+		logger.Info("Window contact closed, re-applying command", "actor", s.Name, "action", pending.Action, "position", pending.Position)
+		// Do not block MQTT processing
+		go s.Submit(*pending)
+	}
+}
+
+// protect stops the actor or limits it to the minimum position if it is
+// moving below the minimum position when the contact opens
+func (s *ShadingActor) protect() {
+	contact := s.device.Contact
+
+	s.mu.Lock()
+	applying := s.applying
+	closing := s.State == "closing"
+	s.mu.Unlock()
+
+	// Without a command in flight the actor is moved by the wall switch and
+	// the target is unknown
+	below := closing
+	if applying != nil {
+		target, moves := s.targetPosition(*applying)
+		below = moves && (target == 0 || target < contact.MinPosition)
+	}
+	if !below || s.safetyLockError() != nil {
+		return
+	}
+
+	s.executor.cancelAndWait()
+
+	s.mu.Lock()
+	position := s.Position
+	if applying != nil && contact.Reapply && s.contactOpen {
+		pending := *applying
+		s.pendingContact = &pending
+	}
+	s.mu.Unlock()
+
+	if contact.Mode == config.ContactModeClamp && contact.MinPosition > 0 && position > contact.MinPosition {
+		logger.Info("Window contact opened while closing, limiting position", "actor", s.Name, "position", contact.MinPosition)
+		if _, err := s.SetPosition(context.Background(), contact.MinPosition); err != nil {
+			logger.Error("Failed to limit position", "actor", s.Name, "error", err)
+		}
+		return
+	}
+
+	logger.Info("Window contact opened while closing, stopping", "actor", s.Name)
+	if _, err := s.Stop(context.Background()); err != nil {
+		logger.Error("Failed to stop actor", "actor", s.Name, "error", err)
+	}
+}
+
+// ContactOpen returns true while the window or door contact reports open
+func (s *ShadingActor) ContactOpen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.contactOpen
+}
+
+// targetPosition returns the position the command moves to and false for
+// commands that do not move the actor
+func (s *ShadingActor) targetPosition(command commands.LLCommand) (int, bool) {
+	switch command.Action {
+	case commands.LLActionSet:
+		return command.Position, true
+	case commands.LLActionTilt:
+		if s.IsRollerShutter() && command.Slat == nil {
+			return s.Config.TiltPosition, true
+		}
+		return command.Position, true
+	case commands.LLActionStep:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return clamp(s.Position+command.Delta, 0, 100), true
+	}
+	return 0, false
+}
+
+// interlock clamps or rejects commands that would move the actor below the
+// minimum position while the contact is open
+func (s *ShadingActor) interlock(command commands.LLCommand) (commands.LLCommand, error) {
+	contact := s.device.Contact
+	if contact == nil {
+		return command, nil
+	}
+
+	target, moves := s.targetPosition(command)
+	if !moves {
+		return command, nil
+	}
+
+	s.mu.Lock()
+	open := s.contactOpen
+	blocked := open && (target == 0 || target < contact.MinPosition)
+	// The last command wins, so an earlier pending command is dropped
+	s.pendingContact = nil
+	if blocked && contact.Reapply {
+		pending := command
+		s.pendingContact = &pending
+	}
+	s.mu.Unlock()
+
+	if !blocked {
+		return command, nil
+	}
+
+	if contact.Mode == config.ContactModeReject || contact.MinPosition <= 0 {
+		return command, fmt.Errorf("%w: position %d is below %d", ErrContactOpen, target, max(contact.MinPosition, 1))
+	}
+
+	logger.Info("Window contact open, limiting position", "actor", s.Name, "requested", target, "position", contact.MinPosition)
+	if command.Action == commands.LLActionStep || (command.Action == commands.LLActionTilt && s.IsRollerShutter() && command.Slat == nil) {
+		command.Action = commands.LLActionSet
+	}
+	command.Position = contact.MinPosition
+	return command, nil
+}
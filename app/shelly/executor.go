@@ -0,0 +1,156 @@
+package shelly
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/philipparndt/go-logger"
+)
+
+// executor runs the commands of a single actor one after another.
+// A new command cancels the command in flight, so the last command wins.
+type executor struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// cancelAndWait cancels the command in flight and waits until it returned,
+// so that it does not send further commands to the device
+func (e *executor) cancelAndWait() {
+	e.mu.Lock()
+	if e.cancel != nil {
+		e.cancel()
+	}
+	done := e.done
+	e.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+func (e *executor) submit(name string, run func(ctx context.Context)) {
+	e.mu.Lock()
+	if e.cancel != nil {
+		e.cancel()
+	}
+	previous := e.done
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	e.cancel = cancel
+	e.done = done
+	e.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic in command processing", "actor", name, "panic", r)
+			}
+		}()
+
+		// Wait for the superseded command to give up before touching the device
+		if previous != nil {
+			<-previous
+		}
+
+		run(ctx)
+	}()
+}
+
+// Submit queues the command on the actor's executor. A command that is still
+// running for this actor is cancelled. The lifecycle of the command is
+// published as command results. An error is returned if the actor does not
+// accept commands, e.g. because it is locked.
+func (s *ShadingActor) Submit(command commands.LLCommand) error {
+	err := s.lockError(command)
+	if err == nil {
+		err = s.acquireHold(command, time.Now())
+	}
+	if err != nil {
+		if command.IsAutomated() {
+			logger.Debug("Suppressing automated command", "actor", s.Name, "action", command.Action, "error", err)
+			return err
+		}
+		logger.Warn("Rejecting command", "actor", s.Name, "action", command.Action, "priority", command.EffectivePriority(), "error", err)
+		PublishRejection(s.Name, command, err.Error())
+		return err
+	}
+
+	s.enqueue(command)
+	return nil
+}
+
+// Enforce queues the command with safety priority regardless of any locks
+// but safety locks. It is meant for protection functions that lock the
+// actor themselves.
+func (s *ShadingActor) Enforce(command commands.LLCommand) error {
+	if err := s.safetyLockError(); err != nil {
+		logger.Warn("Rejecting enforced command", "actor", s.Name, "action", command.Action, "error", err)
+		return err
+	}
+
+	command.Priority = commands.PrioritySafety
+	if err := s.acquireHold(command, time.Now()); err != nil {
+		return err
+	}
+
+	logger.Info("Enforcing command", "actor", s.Name, "action", command.Action, "position", command.Position)
+	s.enqueue(command)
+	return nil
+}
+
+// EmergencyOpen latches a safety lock, cancels the command in flight and
+// opens the actor right away without going through the executor. The safety
+// lock keeps new commands out while waiting for the cancelled one. The
+// context limits waiting for the device to accept the open command.
+func (s *ShadingActor) EmergencyOpen(ctx context.Context, key string, reason string) {
+	s.LockSafety(key, reason)
+	s.executor.cancelAndWait()
+
+	logger.Warn("Emergency open", "actor", s.Name, "reason", reason)
+	if _, err := s.SetPosition(ctx, 100); err != nil {
+		logger.Error("Failed to open actor", "actor", s.Name, "error", err)
+	}
+}
+
+// Halt cancels the command in flight, waits for it to return and stops the
+// actor if it is moving
+func (s *ShadingActor) Halt() {
+	s.executor.cancelAndWait()
+
+	s.mu.Lock()
+	moving := isMoving(s.State)
+	s.mu.Unlock()
+
+	if moving {
+		logger.Info("Halting actor", "actor", s.Name)
+		if _, err := s.Stop(context.Background()); err != nil {
+			logger.Error("Failed to stop actor", "actor", s.Name, "error", err)
+		}
+	}
+}
+
+func (s *ShadingActor) enqueue(command commands.LLCommand) {
+	PublishResult(s.Name, command, ResultAccepted, "")
+
+	s.executor.submit(s.Name, func(ctx context.Context) {
+		if ctx.Err() != nil {
+			logger.Debug("Command superseded before it was started", "actor", s.Name, "action", command.Action)
+			PublishResult(s.Name, command, ResultFailed, "superseded")
+			return
+		}
+
+		PublishResult(s.Name, command, ResultStarted, "")
+		err := s.Apply(ctx, command)
+		if err != nil {
+			PublishResult(s.Name, command, ResultFailed, err.Error())
+			return
+		}
+		PublishResult(s.Name, command, ResultCompleted, "")
+	})
+}
@@ -0,0 +1,97 @@
+package shelly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+var ErrNotSupported = errors.New("not supported by the device")
+
+// gen1Transport uses the roller topics of Gen1 devices like the Shelly 2.5
+// see:
+// https://shelly-api-docs.shelly.cloud/gen1/#shelly2-5-mqtt
+type gen1Transport struct {
+	name      string
+	topicBase string
+	channel   int
+	// uncalibrated is set while the device reports that it is not calibrated
+	uncalibrated atomic.Bool
+}
+
+func (t *gen1Transport) rollerTopic() string {
+	return fmt.Sprintf("%s/roller/%d", t.topicBase, t.channel)
+}
+
+func (t *gen1Transport) subscribe(handle func(coverStatus)) {
+	// The position is published as bare number, -1 if not calibrated
+	mqtt.Subscribe(t.rollerTopic()+"/pos", func(topic string, payload []byte) {
+		logger.Debug("Received MQTT message", "topic", topic, "payload", string(payload))
+
+		position, err := strconv.Atoi(strings.TrimSpace(string(payload)))
+		if err == nil && position == -1 {
+			if !t.uncalibrated.Swap(true) {
+				logger.Warn("Roller is not calibrated, position is unknown", "actor", t.name)
+			}
+			return
+		}
+		if err != nil || position < 0 || position > 100 {
+			logger.Error("Failed to parse position", "actor", t.name, "payload", string(payload))
+			return
+		}
+		if t.uncalibrated.Swap(false) {
+			logger.Info("Roller is calibrated again", "actor", t.name)
+		}
+		handle(coverStatus{Position: &position})
+	})
+
+	// The state is the direction of the movement: open, close or stop
+	mqtt.Subscribe(t.rollerTopic(), func(topic string, payload []byte) {
+		logger.Debug("Received MQTT message", "topic", topic, "payload", string(payload))
+
+		var state string
+		switch strings.TrimSpace(string(payload)) {
+		case "open":
+			state = "opening"
+		case "close":
+			state = "closing"
+		case "stop":
+			state = "stopped"
+		default:
+			logger.Error("Failed to parse state", "actor", t.name, "payload", string(payload))
+			return
+		}
+		handle(coverStatus{State: state})
+	})
+}
+
+func (t *gen1Transport) requestStatus() {
+	mqtt.PublishAbsolute(t.topicBase+"/command", "update", false)
+}
+
+func (t *gen1Transport) setPosition(ctx context.Context, position int) error {
+	switch position {
+	case 0:
+		mqtt.PublishAbsolute(t.rollerTopic()+"/command", "close", false)
+	case 100:
+		mqtt.PublishAbsolute(t.rollerTopic()+"/command", "open", false)
+	default:
+		mqtt.PublishAbsolute(t.rollerTopic()+"/command/pos", strconv.Itoa(position), false)
+	}
+	return nil
+}
+
+func (t *gen1Transport) setSlatPosition(ctx context.Context, position int) error {
+	return fmt.Errorf("slat position: %w", ErrNotSupported)
+}
+
+func (t *gen1Transport) stop(ctx context.Context) error {
+	mqtt.PublishAbsolute(t.rollerTopic()+"/command", "stop", false)
+	return nil
+}
@@ -0,0 +1,99 @@
+package shelly
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/philipparndt/go-logger"
+)
+
+var ErrHeld = errors.New("actor is held by a higher priority")
+
+// Hold rejects commands with a lower priority until it expires
+type Hold struct {
+	Priority commands.Priority `json:"priority"`
+	Until    time.Time         `json:"until"`
+	Reason   string            `json:"reason"`
+}
+
+// holdDuration returns the hold of the command or the configured hold of its priority
+func holdDuration(command commands.LLCommand) time.Duration {
+	if command.Hold != nil {
+		return time.Duration(*command.Hold) * time.Second
+	}
+	return time.Duration(config.Get().Priorities.Hold[command.EffectivePriority().String()]) * time.Second
+}
+
+// Hold returns the active hold or nil if the actor is not held
+func (s *ShadingActor) Hold() *Hold {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hold == nil || !time.Now().Before(s.hold.Until) {
+		return nil
+	}
+	hold := *s.hold
+	return &hold
+}
+
+// ReleaseHold removes the active hold and ends the manual override. It
+// returns false if there was neither.
+func (s *ShadingActor) ReleaseHold() bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	released := s.hold != nil && now.Before(s.hold.Until)
+	s.hold = nil
+	if s.manual != nil && now.Before(s.manual.Until) {
+		s.manual.Until = now
+		released = true
+	}
+	s.mu.Unlock()
+
+	if released {
+		logger.Info("Hold released", "actor", s.Name)
+		s.notifyChange()
+	}
+	return released
+}
+
+// acquireHold rejects the command if a hold with a higher priority is active.
+// Otherwise, the hold of the command is applied.
+func (s *ShadingActor) acquireHold(command commands.LLCommand, now time.Time) error {
+	priority := command.EffectivePriority()
+	reason := fmt.Sprintf("%s command", command.Action)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hold != nil && now.Before(s.hold.Until) && priority < s.hold.Priority {
+		return fmt.Errorf("%w: %s hold until %s (%s)", ErrHeld, s.hold.Priority, s.hold.Until.Format(time.RFC3339), s.hold.Reason)
+	}
+	// The manual override lasts on its own, a higher priority hold may
+	// have replaced the manual hold in the meantime
+	if s.manual != nil && now.Before(s.manual.Until) && priority < commands.PriorityManual {
+		return fmt.Errorf("%w: manual override until %s (via %s)", ErrHeld, s.manual.Until.Format(time.RFC3339), s.manual.Source)
+	}
+
+	s.extendHold(priority, now.Add(holdDuration(command)), reason)
+	return nil
+}
+
+// extendHold sets the hold unless the active hold has a higher priority or
+// lasts longer with the same priority. The caller must hold mu.
+func (s *ShadingActor) extendHold(priority commands.Priority, until time.Time, reason string) {
+	if !until.After(time.Now()) {
+		return
+	}
+	if s.hold != nil && time.Now().Before(s.hold.Until) {
+		if priority < s.hold.Priority || (priority == s.hold.Priority && !until.After(s.hold.Until)) {
+			return
+		}
+	}
+
+	logger.Debug("Actor held", "actor", s.Name, "priority", priority, "until", until, "reason", reason)
+	s.hold = &Hold{Priority: priority, Until: until, Reason: reason}
+}
@@ -0,0 +1,190 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/philipparndt/go-logger"
+)
+
+// movingPollInterval is used instead of the polling interval while the
+// cover is moving, so that waiting for a position does not time out
+const movingPollInterval = time.Second
+
+var httpClient = &http.Client{Timeout: rpcTimeout}
+
+// httpTransport calls the RPC methods of Gen2 devices via HTTP and polls the
+// status, for devices that are not connected to the MQTT broker
+// see:
+// https://shelly-api-docs.shelly.cloud/gen2/General/RPCChannels#http
+type httpTransport struct {
+	name     string
+	address  string
+	channel  int
+	interval time.Duration
+	handle   func(coverStatus)
+	// wake triggers a poll, e.g. after a command has been sent
+	wake chan struct{}
+}
+
+func newHTTPTransport(name string, address string, channel int, interval time.Duration) *httpTransport {
+	return &httpTransport{
+		name:     name,
+		address:  address,
+		channel:  channel,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// call sends a GET request to /rpc/<method> and decodes the result
+func (t *httpTransport) call(ctx context.Context, method string, params url.Values, result any) error {
+	params.Set("id", strconv.Itoa(t.channel))
+	address := fmt.Sprintf("http://%s/rpc/%s?%s", t.address, method, params.Encode())
+
+	logger.Debug("Sending HTTP RPC request", "actor", t.name, "url", address)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		rpcErr := &RPCError{}
+		if err := json.Unmarshal(body, rpcErr); err != nil || rpcErr.Message == "" {
+			return fmt.Errorf("%s: unexpected HTTP status %s", method, response.Status)
+		}
+		return fmt.Errorf("%s: %w", method, rpcErr)
+	}
+
+	if result != nil {
+		return json.Unmarshal(body, result)
+	}
+	return nil
+}
+
+func (t *httpTransport) subscribe(handle func(coverStatus)) {
+	t.handle = handle
+	go t.run()
+}
+
+// run polls the status in the polling interval, or faster while moving
+func (t *httpTransport) run() {
+	logger.Info("Polling status via HTTP", "actor", t.name, "address", t.address, "interval", t.interval)
+
+	for {
+		delay := t.interval
+		if t.poll() {
+			delay = movingPollInterval
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-t.wake:
+		}
+	}
+}
+
+// poll fetches the status and returns whether the cover is moving
+func (t *httpTransport) poll() bool {
+	status := Status{}
+	if err := t.call(context.Background(), "Cover.GetStatus", url.Values{}, &status); err != nil {
+		logger.Error("Failed to poll status", "actor", t.name, "address", t.address, "error", err)
+		return false
+	}
+
+	t.handle(status.coverStatus())
+	return isMoving(status.State)
+}
+
+func (t *httpTransport) requestStatus() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// command calls the method and polls the status to follow the movement
+func (t *httpTransport) command(ctx context.Context, method string, params url.Values) error {
+	err := t.call(ctx, method, params, nil)
+	if err == nil {
+		t.requestStatus()
+	}
+	return err
+}
+
+func (t *httpTransport) setPosition(ctx context.Context, position int) error {
+	switch position {
+	case 0:
+		return t.command(ctx, "Cover.Close", url.Values{})
+	case 100:
+		return t.command(ctx, "Cover.Open", url.Values{})
+	default:
+		return t.command(ctx, "Cover.GoToPosition", url.Values{"pos": {strconv.Itoa(position)}})
+	}
+}
+
+func (t *httpTransport) setSlatPosition(ctx context.Context, position int) error {
+	return t.command(ctx, "Cover.GoToPosition", url.Values{"slat_pos": {strconv.Itoa(position)}})
+}
+
+func (t *httpTransport) stop(ctx context.Context) error {
+	return t.command(ctx, "Cover.Stop", url.Values{})
+}
+
+// fallbackTransport uses the RPC transport and polls the status via HTTP.
+// Commands that could not be published or got no response are sent via HTTP.
+type fallbackTransport struct {
+	transport
+	http *httpTransport
+}
+
+func (t *fallbackTransport) subscribe(handle func(coverStatus)) {
+	t.transport.subscribe(handle)
+	t.http.subscribe(handle)
+}
+
+// fallback sends the command via HTTP if it was not published or the device
+// did not respond via MQTT
+func (t *fallbackTransport) fallback(err error, send func() error) error {
+	if !errors.Is(err, ErrRPCTimeout) && !errors.Is(err, ErrNotPublished) {
+		return err
+	}
+
+	logger.Warn("No response via MQTT, sending command via HTTP", "actor", t.http.name, "address", t.http.address, "error", err)
+	return send()
+}
+
+func (t *fallbackTransport) setPosition(ctx context.Context, position int) error {
+	return t.fallback(t.transport.setPosition(ctx, position), func() error {
+		return t.http.setPosition(ctx, position)
+	})
+}
+
+func (t *fallbackTransport) setSlatPosition(ctx context.Context, position int) error {
+	return t.fallback(t.transport.setSlatPosition(ctx, position), func() error {
+		return t.http.setSlatPosition(ctx, position)
+	})
+}
+
+func (t *fallbackTransport) stop(ctx context.Context) error {
+	return t.fallback(t.transport.stop(ctx), func() error {
+		return t.http.stop(ctx)
+	})
+}
@@ -0,0 +1,130 @@
+package shelly
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/philipparndt/go-logger"
+)
+
+var ErrLocked = errors.New("actor is locked")
+
+// Lock prevents an actor from executing commands until it is released by
+// its owner
+type Lock struct {
+	Key    string    `json:"key"`
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+	// Forceable locks let manual commands with the force flag pass
+	Forceable bool `json:"forceable,omitempty"`
+	// Safety locks reject enforced commands as well
+	Safety bool `json:"safety,omitempty"`
+}
+
+// Lock locks the actor. It returns false if the lock was already held.
+func (s *ShadingActor) Lock(key string, reason string) bool {
+	return s.AddLock(Lock{Key: key, Reason: reason, Since: time.Now()})
+}
+
+// LockForceable locks the actor against automated commands and manual
+// commands without the force flag. Stop commands are always accepted.
+func (s *ShadingActor) LockForceable(key string, reason string) bool {
+	return s.AddLock(Lock{Key: key, Reason: reason, Since: time.Now(), Forceable: true})
+}
+
+// LockSafety locks the actor against all commands including enforced ones
+func (s *ShadingActor) LockSafety(key string, reason string) bool {
+	return s.AddLock(Lock{Key: key, Reason: reason, Since: time.Now(), Safety: true})
+}
+
+// AddLock adds the lock as is, e.g. to restore a persisted lock. It returns
+// false if a lock with the key was already held.
+func (s *ShadingActor) AddLock(lock Lock) bool {
+	s.mu.Lock()
+	if _, ok := s.locks[lock.Key]; ok {
+		s.mu.Unlock()
+		return false
+	}
+	s.locks[lock.Key] = lock
+	s.mu.Unlock()
+
+	logger.Warn("Actor locked", "actor", s.Name, "key", lock.Key, "reason", lock.Reason, "forceable", lock.Forceable)
+	s.notifyChange()
+	return true
+}
+
+// Unlock releases the lock. It returns false if the lock was not held.
+func (s *ShadingActor) Unlock(key string) bool {
+	s.mu.Lock()
+	if _, ok := s.locks[key]; !ok {
+		s.mu.Unlock()
+		return false
+	}
+	delete(s.locks, key)
+	s.mu.Unlock()
+
+	logger.Info("Actor unlocked", "actor", s.Name, "key", key)
+	s.notifyChange()
+	return true
+}
+
+// Locks returns the locks held on the actor, oldest first
+func (s *ShadingActor) Locks() []Lock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locks := make([]Lock, 0, len(s.locks))
+	for _, lock := range s.locks {
+		locks = append(locks, lock)
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].Since.Before(locks[j].Since)
+	})
+	return locks
+}
+
+func (s *ShadingActor) IsLocked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.locks) > 0
+}
+
+// lockError returns an error describing the locks that reject the command or
+// nil if the command may pass
+func (s *ShadingActor) lockError(command commands.LLCommand) error {
+	var reasons []string
+	for _, lock := range s.Locks() {
+		if lock.Forceable && !command.IsAutomated() && (command.Force || command.Action == commands.LLActionStop) {
+			continue
+		}
+		if lock.Forceable && !command.IsAutomated() {
+			reasons = append(reasons, lock.Reason+" (use force to override)")
+			continue
+		}
+		reasons = append(reasons, lock.Reason)
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrLocked, strings.Join(reasons, "; "))
+}
+
+// safetyLockError returns an error describing the safety locks or nil if there are none
+func (s *ShadingActor) safetyLockError() error {
+	var reasons []string
+	for _, lock := range s.Locks() {
+		if lock.Safety {
+			reasons = append(reasons, lock.Reason)
+		}
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrLocked, strings.Join(reasons, "; "))
+}
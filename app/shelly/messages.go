@@ -1,5 +1,10 @@
 package shelly
 
 type PositionMessage struct {
-	Position int `json:"position"`
+	Position     int    `json:"position"`
+	SlatPosition int    `json:"slatPosition"`
+	Tilted       bool   `json:"tilted"`
+	State        string `json:"state"`
+	Moving       bool   `json:"moving"`
+	Locked       bool   `json:"locked"`
 }
@@ -0,0 +1,60 @@
+package shelly
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/philipparndt/go-logger"
+)
+
+// ManualInteraction describes the last time the actor was moved by a person
+type ManualInteraction struct {
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+	// Until is the end of the override window
+	Until time.Time `json:"until"`
+}
+
+func isManualSource(source string) bool {
+	for _, manual := range config.Get().Override.Sources {
+		if strings.EqualFold(source, manual) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSource remembers a manual interaction if a movement was started by a
+// manual source and holds the actor with manual priority for the override
+// duration. The caller must hold mu.
+func (s *ShadingActor) recordSource(source string) {
+	if source == "" || !isManualSource(source) {
+		return
+	}
+
+	now := time.Now()
+	if s.manual == nil || now.After(s.manual.Until) {
+		logger.Info("Manual interaction detected", "actor", s.Name, "source", source)
+	}
+
+	s.manual = &ManualInteraction{
+		Source: source,
+		Time:   now,
+		Until:  now.Add(time.Duration(config.Get().Override.Duration) * time.Second),
+	}
+	s.extendHold(commands.PriorityManual, s.manual.Until, "manual interaction via "+source)
+}
+
+// ManualInteraction returns the last manual interaction or nil if there was none
+func (s *ShadingActor) ManualInteraction() *ManualInteraction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.manual == nil {
+		return nil
+	}
+	manual := *s.manual
+	return &manual
+}
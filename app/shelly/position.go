@@ -1,14 +1,13 @@
 package shelly
 
 import (
+	"context"
 	"fmt"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/mqtt-home/shelly-commands/retry"
 	"github.com/philipparndt/go-logger"
-	"github.com/philipparndt/mqtt-gateway/mqtt"
 )
 
 // PositionChangeEvent is sent to the global channel when an actor's position changes
@@ -39,94 +38,108 @@ func (s *ShadingActor) getPosition() (int, error) {
 	return s.Position, nil
 }
 
-func (s *ShadingActor) SetPosition(position int) (bool, error) {
+// SetPosition moves to the position. The context aborts waiting for the
+// response of the device.
+func (s *ShadingActor) SetPosition(ctx context.Context, position int) (bool, error) {
 	if position < 0 || position > 100 {
 		return false, fmt.Errorf("invalid position")
 	}
-	// see:
-	// https://shelly-api-docs.shelly.cloud/gen2/ComponentsAndServices/Cover#mqtt-control
 
 	if position == 0 {
 		logger.Debug("Close blinds", "actor", s.Name)
-		mqtt.PublishAbsolute(s.TopicBase+"/command/cover:0", "close", false)
 	} else if position == 100 {
 		logger.Debug("Open blinds", "actor", s.Name)
-		mqtt.PublishAbsolute(s.TopicBase+"/command/cover:0", "open", false)
 	} else {
 		logger.Debug("Set blinds position", "actor", s.Name, "to", position)
-		mqtt.PublishAbsolute(s.TopicBase+"/command/cover:0", "pos,"+strconv.Itoa(position), false)
 	}
 
+	if err := s.transport.setPosition(ctx, position); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
-func (s *ShadingActor) SetSlatPosition(position int) (bool, error) {
+func (s *ShadingActor) SetSlatPosition(ctx context.Context, position int) (bool, error) {
 	if position < 0 || position > 100 {
 		return false, fmt.Errorf("invalid slat position")
 	}
 
-	// see:
-	// https://shelly-api-docs.shelly.cloud/gen2/ComponentsAndServices/Cover#mqtt-control
+	if err := s.transport.setSlatPosition(ctx, position); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	mqtt.PublishAbsolute(s.TopicBase+"/command/cover:0", "slat_pos,"+strconv.Itoa(position), false)
+func (s *ShadingActor) Stop(ctx context.Context) (bool, error) {
+	logger.Debug("Stop blinds", "actor", s.Name)
 
+	if err := s.transport.stop(ctx); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
-func (s *ShadingActor) WaitForPosition(waitGroup *sync.WaitGroup, position int, timeout int) error {
-	if position < 0 || position > 100 {
-		return fmt.Errorf("invalid position")
+// WaitResult is the outcome of waiting for a position
+type WaitResult int
+
+const (
+	WaitReached WaitResult = iota
+	WaitTimeout
+	WaitCancelled
+)
+
+func (r WaitResult) String() string {
+	switch r {
+	case WaitReached:
+		return "reached"
+	case WaitTimeout:
+		return "timeout"
+	case WaitCancelled:
+		return "cancelled"
 	}
+	return "unknown"
+}
 
+// WaitForPosition blocks until the device reports the position, the timeout
+// elapsed or the context is cancelled. It wakes up on every status update
+// received from the device.
+func (s *ShadingActor) WaitForPosition(ctx context.Context, position int, timeout time.Duration) WaitResult {
 	logger.Info("Starting position wait", "actor", s.Name, "target_position", position, "timeout", timeout)
-	waitGroup.Add(1)
-
-	go func() {
-		defer func() {
-			logger.Debug("Position wait goroutine finishing", "actor", s.Name, "target", position)
-			waitGroup.Done()
-		}()
-
-		startTime := time.Now()
-		checkCount := 0
-
-		for {
-			checkCount++
-			currentPosition, err := s.GetPosition()
-			if err != nil {
-				logger.Error("Failed to get position during wait", "actor", s.Name, "error", err)
-				return
-			}
-
-			if currentPosition == position {
-				logger.Info("Position reached successfully", "actor", s.Name, "position", position, "checks", checkCount, "duration", time.Since(startTime))
-				return
-			}
-
-			elapsed := time.Since(startTime)
-			logger.Debug("Waiting for position", "actor", s.Name, "target", position, "current", currentPosition, "elapsed", elapsed, "checks", checkCount)
-
-			if elapsed.Seconds() > float64(timeout) {
-				logger.Error("Timeout waiting for position", "actor", s.Name, "target", position, "current", currentPosition, "timeout", timeout, "checks", checkCount)
-				return
-			}
-
-			time.Sleep(500 * time.Millisecond)
+
+	startTime := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		currentPosition := s.Position
+		changed := s.statusChanged
+		s.mu.Unlock()
+
+		if currentPosition == position {
+			logger.Info("Position reached successfully", "actor", s.Name, "position", position, "duration", time.Since(startTime))
+			return WaitReached
 		}
-	}()
 
-	return nil
-}
+		logger.Debug("Waiting for position", "actor", s.Name, "target", position, "current", currentPosition, "elapsed", time.Since(startTime))
 
-func (s *ShadingActor) SetAndWaitForPosition(waitGroup *sync.WaitGroup, position int, timeout int) error {
-	if position < 0 || position > 100 {
-		return fmt.Errorf("invalid position")
+		select {
+		case <-changed:
+		case <-timer.C:
+			logger.Error("Timeout waiting for position", "actor", s.Name, "target", position, "current", currentPosition, "timeout", timeout)
+			return WaitTimeout
+		case <-ctx.Done():
+			logger.Debug("Position wait cancelled", "actor", s.Name, "target", position)
+			return WaitCancelled
+		}
 	}
+}
 
-	_, err := s.SetPosition(position)
+func (s *ShadingActor) SetAndWaitForPosition(ctx context.Context, position int, timeout time.Duration) (WaitResult, error) {
+	_, err := s.SetPosition(ctx, position)
 	if err != nil {
-		return err
+		return WaitCancelled, err
 	}
 
-	return s.WaitForPosition(waitGroup, position, timeout)
+	return s.WaitForPosition(ctx, position, timeout), nil
 }
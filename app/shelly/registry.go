@@ -1,10 +1,13 @@
 package shelly
 
 import (
+	"fmt"
 	"strings"
 	"sync"
 )
 
+const GroupPrefix = "group:"
+
 type ActorRegistry struct {
 	Actors map[string]*ShadingActor
 	mu     sync.RWMutex
@@ -16,10 +19,24 @@ func NewActorRegistry() *ActorRegistry {
 	}
 }
 
-func (r *ActorRegistry) AddActor(actor *ShadingActor) {
+// AddActor registers the actor. Actor names must be unique, actors may share
+// a topic base or address if they use different channels.
+func (r *ActorRegistry) AddActor(actor *ShadingActor) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.Actors[strings.ToLower(actor.Name)] = actor
+
+	key := strings.ToLower(actor.Name)
+	if _, ok := r.Actors[key]; ok {
+		return fmt.Errorf("duplicate actor %s", actor.Name)
+	}
+	for _, other := range r.Actors {
+		if other.Endpoint() == actor.Endpoint() && other.Channel == actor.Channel {
+			return fmt.Errorf("actors %s and %s use the same component %s of %s", other.Name, actor.Name, actor.Component(), actor.Endpoint())
+		}
+	}
+
+	r.Actors[key] = actor
+	return nil
 }
 
 func (r *ActorRegistry) GetActor(name string) *ShadingActor {
@@ -28,12 +45,13 @@ func (r *ActorRegistry) GetActor(name string) *ShadingActor {
 	return r.Actors[strings.ToLower(name)]
 }
 
-func (r *ActorRegistry) GetActorBySN(sn string) *ShadingActor {
+// GetActorBySN returns the actor for the channel of the device with the serial number
+func (r *ActorRegistry) GetActorBySN(sn string, channel int) *ShadingActor {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, actor := range r.Actors {
-		if actor.Serial == sn {
+		if actor.Serial == sn && actor.Channel == channel {
 			return actor
 		}
 	}
@@ -81,3 +99,22 @@ func (r *ActorRegistry) GetAllGroups() map[string][]*ShadingActor {
 	}
 	return groups
 }
+
+// Resolve returns the actors for a command target, which is either an actor
+// name or group:<group-id>
+func (r *ActorRegistry) Resolve(target string) ([]*ShadingActor, error) {
+	if strings.HasPrefix(target, GroupPrefix) {
+		groupID := strings.TrimPrefix(target, GroupPrefix)
+		actors := r.GetActorsByGroup(groupID)
+		if len(actors) == 0 {
+			return nil, fmt.Errorf("no actors found for group %s", groupID)
+		}
+		return actors, nil
+	}
+
+	actor := r.GetActor(target)
+	if actor == nil {
+		return nil, fmt.Errorf("unknown actor %s", target)
+	}
+	return []*ShadingActor{actor}, nil
+}
@@ -0,0 +1,104 @@
+package shelly
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+type ResultStatus string
+
+const (
+	ResultAccepted  ResultStatus = "accepted"
+	ResultStarted   ResultStatus = "started"
+	ResultCompleted ResultStatus = "completed"
+	ResultFailed    ResultStatus = "failed"
+)
+
+// CommandResult is published for every lifecycle step of a command that
+// carries an ID or a response topic
+type CommandResult struct {
+	ID        string            `json:"id,omitempty"`
+	Actor     string            `json:"actor"`
+	Action    commands.LLAction `json:"action"`
+	Status    ResultStatus      `json:"status"`
+	Reason    string            `json:"reason,omitempty"`
+	Timestamp string            `json:"timestamp"`
+}
+
+type publication struct {
+	topic   string
+	payload string
+}
+
+var (
+	resultQueue     = make(chan publication, 100)
+	resultQueueOnce sync.Once
+)
+
+// publishResults publishes the queued results in order. Results are queued so
+// that callers running inside MQTT callbacks never wait for the broker.
+func publishResults() {
+	for p := range resultQueue {
+		mqtt.PublishAbsolute(p.topic, p.payload, false)
+	}
+}
+
+// ResultTopic returns the default topic for command results of the target
+func ResultTopic(target string) string {
+	return config.Get().MQTT.Topic + "/" + target + "/result"
+}
+
+// PublishResult publishes a command result for the target (actor name,
+// group or scene). Nothing is published if the command did not ask for it.
+func PublishResult(target string, command commands.LLCommand, status ResultStatus, reason string) {
+	if !command.WantsResult() {
+		return
+	}
+	publishResult(target, command, status, reason)
+}
+
+// PublishRejection publishes a failed result for a command that was not
+// accepted, even if the command did not ask for results
+func PublishRejection(target string, command commands.LLCommand, reason string) {
+	publishResult(target, command, ResultFailed, reason)
+}
+
+func publishResult(target string, command commands.LLCommand, status ResultStatus, reason string) {
+	topic := command.ResponseTopic
+	if topic == "" {
+		topic = ResultTopic(target)
+	}
+
+	result := CommandResult{
+		ID:        command.ID,
+		Actor:     target,
+		Action:    command.Action,
+		Status:    status,
+		Reason:    reason,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		logger.Error("Failed to marshal command result", "target", target, "error", err)
+		return
+	}
+
+	resultQueueOnce.Do(func() {
+		go publishResults()
+	})
+
+	// Non-blocking send to the result queue
+	select {
+	case resultQueue <- publication{topic: topic, payload: string(data)}:
+		logger.Debug("Command result queued", "target", target, "id", command.ID, "status", status)
+	default:
+		logger.Warn("Command result queue is full, dropping result", "target", target, "id", command.ID, "status", status)
+	}
+}
@@ -0,0 +1,226 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+// rpcTimeout is the maximum time to wait for the response of a device
+const rpcTimeout = 5 * time.Second
+
+var (
+	ErrRPCTimeout   = errors.New("no response from device")
+	ErrNotPublished = errors.New("request not published to the broker")
+)
+
+// RPCError is an error returned by the device, e.g. if the cover is not
+// calibrated or the safety switch is active
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("device error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	ID     int64  `json:"id"`
+	Src    string `json:"src"`
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     int64           `json:"id"`
+	Src    string          `json:"src"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// rpcClient sends JSON-RPC frames over MQTT and matches the responses that
+// the devices publish to <src>/rpc
+// see:
+// https://shelly-api-docs.shelly.cloud/gen2/General/RPCChannels#mqtt
+type rpcClient struct {
+	mu      sync.Mutex
+	src     string
+	nextID  atomic.Int64
+	pending map[int64]chan rpcResponse
+}
+
+var (
+	sharedRPC *rpcClient
+	rpcOnce   sync.Once
+)
+
+// getRPCClient returns the shared client and subscribes to the responses on
+// first use
+func getRPCClient() *rpcClient {
+	rpcOnce.Do(func() {
+		sharedRPC = &rpcClient{
+			src:     config.Get().MQTT.Topic + "-rpc",
+			pending: make(map[int64]chan rpcResponse),
+		}
+		sharedRPC.nextID.Store(time.Now().UnixMilli())
+		mqtt.Subscribe(sharedRPC.src+"/rpc", sharedRPC.handleResponse)
+	})
+	return sharedRPC
+}
+
+func (c *rpcClient) handleResponse(topic string, payload []byte) {
+	logger.Debug("Received RPC response", "topic", topic, "payload", string(payload))
+
+	response := rpcResponse{}
+	if err := json.Unmarshal(payload, &response); err != nil {
+		logger.Error("Failed to parse RPC response", "topic", topic, "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	ch := c.pending[response.ID]
+	delete(c.pending, response.ID)
+	c.mu.Unlock()
+
+	if ch == nil {
+		logger.Debug("Ignoring RPC response without pending request", "id", response.ID, "src", response.Src)
+		return
+	}
+	// Buffered, never blocks the MQTT processing
+	ch <- response
+}
+
+// call publishes the request to <topicBase>/rpc and waits for the response
+// until the timeout or the context is done. Must not be called from within an
+// MQTT callback.
+func (c *rpcClient) call(ctx context.Context, topicBase string, method string, params any, result any) error {
+	request := rpcRequest{
+		ID:     c.nextID.Add(1),
+		Src:    c.src,
+		Method: method,
+		Params: params,
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan rpcResponse, 1)
+	c.mu.Lock()
+	c.pending[request.ID] = ch
+	c.mu.Unlock()
+
+	// The timeout covers publishing and waiting for the response
+	timeout := time.NewTimer(rpcTimeout)
+	defer timeout.Stop()
+
+	logger.Debug("Sending RPC request", "topic", topicBase+"/rpc", "payload", string(data))
+	if err := publish(ctx, timeout.C, topicBase+"/rpc", string(data)); err != nil {
+		c.forget(request.ID)
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case response := <-ch:
+		if response.Error != nil {
+			return fmt.Errorf("%s: %w", method, response.Error)
+		}
+		if result != nil && len(response.Result) > 0 {
+			return json.Unmarshal(response.Result, result)
+		}
+		return nil
+	case <-ctx.Done():
+		c.forget(request.ID)
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	case <-timeout.C:
+		c.forget(request.ID)
+		return fmt.Errorf("%s: %w", method, ErrRPCTimeout)
+	}
+}
+
+// publish publishes the message but returns once the context is done or the
+// timeout fires. mqtt.PublishAbsolute waits for the broker, and while
+// reconnecting, messages are stored until the broker is back.
+func publish(ctx context.Context, timeout <-chan time.Time, topic string, message string) error {
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		mqtt.PublishAbsolute(topic, message, false)
+	}()
+
+	select {
+	case <-published:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return ErrNotPublished
+	}
+}
+
+// forget drops a request that is no longer awaited
+func (c *rpcClient) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+type coverParams struct {
+	ID      int  `json:"id"`
+	Pos     *int `json:"pos,omitempty"`
+	SlatPos *int `json:"slat_pos,omitempty"`
+}
+
+// rpcTransport sends the commands as JSON-RPC frames to Gen2 devices and
+// reports the errors of the device. Status updates are received like with
+// the string commands.
+type rpcTransport struct {
+	gen2Transport
+	handle func(coverStatus)
+}
+
+func (t *rpcTransport) subscribe(handle func(coverStatus)) {
+	t.handle = handle
+	t.gen2Transport.subscribe(handle)
+}
+
+func (t *rpcTransport) requestStatus() {
+	// The response is awaited, do not block the caller
+	go func() {
+		status := Status{}
+		err := getRPCClient().call(context.Background(), t.topicBase, "Cover.GetStatus", coverParams{ID: t.channel}, &status)
+		if err != nil {
+			logger.Error("Failed to request status", "actor", t.name, "error", err)
+			return
+		}
+		t.handle(status.coverStatus())
+	}()
+}
+
+func (t *rpcTransport) setPosition(ctx context.Context, position int) error {
+	switch position {
+	case 0:
+		return getRPCClient().call(ctx, t.topicBase, "Cover.Close", coverParams{ID: t.channel}, nil)
+	case 100:
+		return getRPCClient().call(ctx, t.topicBase, "Cover.Open", coverParams{ID: t.channel}, nil)
+	default:
+		return getRPCClient().call(ctx, t.topicBase, "Cover.GoToPosition", coverParams{ID: t.channel, Pos: &position}, nil)
+	}
+}
+
+func (t *rpcTransport) setSlatPosition(ctx context.Context, position int) error {
+	return getRPCClient().call(ctx, t.topicBase, "Cover.GoToPosition", coverParams{ID: t.channel, SlatPos: &position}, nil)
+}
+
+func (t *rpcTransport) stop(ctx context.Context) error {
+	return getRPCClient().call(ctx, t.topicBase, "Cover.Stop", coverParams{ID: t.channel}, nil)
+}
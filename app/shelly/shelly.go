@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/mqtt-home/shelly-commands/commands"
 	"github.com/mqtt-home/shelly-commands/config"
 	"github.com/philipparndt/go-logger"
 	"github.com/philipparndt/mqtt-gateway/mqtt"
@@ -14,17 +15,36 @@ type ShadingActor struct {
 	device       config.Device
 	Name         string
 	TopicBase    string
+	Channel      int
 	Serial       string
 	Config       config.BlindsConfig
 	DeviceType   config.DeviceType
 	Tilted       bool
 	TiltPosition int
 	Position     int
+	State        string
 	Rank         int
 	GroupIDs     []string
 	// Deprecated: Use GroupIDs instead. Kept for backward compatibility.
 	GroupID string
 	mu      sync.Mutex
+
+	publishMu     sync.Mutex
+	lastPublished *PositionMessage
+	executor      executor
+	// statusChanged is closed and replaced whenever a status update arrives
+	statusChanged chan struct{}
+	locks         map[string]Lock
+	transport     transport
+	// manual is the last interaction from a manual source like the wall switch
+	manual *ManualInteraction
+	hold   *Hold
+
+	contactOpen bool
+	// pendingContact is re-applied once the contact closes
+	pendingContact *commands.LLCommand
+	// applying is the command currently applied by the executor
+	applying *commands.LLCommand
 }
 
 func NewShadingActor(device config.Device) *ShadingActor {
@@ -33,12 +53,17 @@ func NewShadingActor(device config.Device) *ShadingActor {
 		device:     device,
 		Name:       device.Name,
 		TopicBase:  device.TopicBase,
+		Channel:    device.Channel,
 		Config:     device.BlindsConfig,
 		DeviceType: device.DeviceType,
 		Tilted:     false,
 		Rank:       device.Rank,
 		GroupIDs:   groupIDs,
 		GroupID:    device.GroupID, // Keep for backward compatibility
+
+		statusChanged: make(chan struct{}),
+		locks:         make(map[string]Lock),
+		transport:     newTransport(device),
 	}
 	err := actor.init()
 	if err != nil {
@@ -51,12 +76,28 @@ func (s *ShadingActor) init() error {
 	return nil
 }
 
+// Device returns the configuration the actor was created from
+func (s *ShadingActor) Device() config.Device {
+	return s.device
+}
+
 func (s *ShadingActor) DisplayName() string {
 	return s.Name
 }
 
 func (s *ShadingActor) String() string {
-	return fmt.Sprintf("ShadingActor{name: %s; topic_base: %s; type: %s}", s.Name, s.TopicBase, s.DeviceType)
+	return fmt.Sprintf("ShadingActor{name: %s; topic_base: %s; channel: %d; type: %s}", s.Name, s.TopicBase, s.Channel, s.DeviceType)
+}
+
+// Component returns the Shelly cover component of the actor, e.g. cover:0
+func (s *ShadingActor) Component() string {
+	return fmt.Sprintf("cover:%d", s.Channel)
+}
+
+// Endpoint returns the topic base, or the address of devices only reachable
+// via HTTP
+func (s *ShadingActor) Endpoint() string {
+	return s.device.Endpoint()
 }
 
 func (s *ShadingActor) IsRollerShutter() bool {
@@ -83,40 +124,106 @@ func (s *ShadingActor) IsInGroup(groupID string) bool {
 }
 
 func (s *ShadingActor) Start() error {
-	mqtt.Subscribe(s.TopicBase+"/status/cover:0", func(topic string, payload []byte) {
-		logger.Debug("Received MQTT message", "topic", topic, "payload", string(payload))
+	s.transport.subscribe(s.handleStatus)
+	s.subscribeContact()
 
-		status := &Status{}
+	s.transport.requestStatus()
+	logger.Info("Actor started and subscribed to MQTT", "actor", s.Name, "endpoint", s.Endpoint(), "component", s.Component(), "protocol", s.device.Protocol)
 
-		err := json.Unmarshal(payload, status)
-		if err != nil {
-			logger.Error("Failed to parse status", "actor", s.Name, "error", err)
-			return
-		}
+	return nil
+}
 
-		// Safely update position with mutex
-		s.mu.Lock()
-		oldPosition := s.Position
-		oldTiltPosition := s.TiltPosition
-		s.Position = status.CurrentPos
-		s.TiltPosition = status.SlatPos
-		s.Tilted = status.SlatPos != 0
-		s.mu.Unlock()
+// handleStatus applies a status update of the device
+func (s *ShadingActor) handleStatus(status coverStatus) {
+	// Safely update position with mutex
+	s.mu.Lock()
+	oldPosition := s.Position
+	oldTiltPosition := s.TiltPosition
+	oldState := s.State
+	if status.Position != nil {
+		s.Position = *status.Position
+	}
+	if status.SlatPosition != nil {
+		s.TiltPosition = *status.SlatPosition
+		s.Tilted = *status.SlatPosition != 0
+	}
+	if status.State != "" {
+		s.State = status.State
+	}
+	// Later status updates still carry the source of the last command, only a
+	// new movement is an interaction
+	if s.State != oldState && isMoving(s.State) {
+		s.recordSource(status.Source)
+	}
+	close(s.statusChanged)
+	s.statusChanged = make(chan struct{})
+	position := s.Position
+	tiltPosition := s.TiltPosition
+	s.mu.Unlock()
 
-		logger.Debug("Position updated", "actor", s.Name, "from", oldPosition, "to", status.CurrentPos, "tilt_from", oldTiltPosition, "tilt_to", status.SlatPos)
+	logger.Debug("Position updated", "actor", s.Name, "from", oldPosition, "to", position, "tilt_from", oldTiltPosition, "tilt_to", tiltPosition)
 
-		// Non-blocking send to position change channel
-		event := PositionChangeEvent{ActorName: s.Name, Position: status.CurrentPos, SlatPosition: status.SlatPos}
-		select {
-		case PositionChangeChan <- event:
-			logger.Debug("Position change event sent", "actor", s.Name, "position", status.CurrentPos)
-		default:
-			logger.Warn("Position change channel is full, dropping event", "actor", s.Name, "position", status.CurrentPos)
-		}
-	})
+	s.notifyChange()
+}
 
-	mqtt.PublishAbsolute(s.TopicBase+"/command/cover:0", "status_update", false)
-	logger.Info("Actor started and subscribed to MQTT", "actor", s.Name, "topic_base", s.TopicBase)
+// notifyChange informs the web interface and publishes the actor state
+func (s *ShadingActor) notifyChange() {
+	s.mu.Lock()
+	event := PositionChangeEvent{ActorName: s.Name, Position: s.Position, SlatPosition: s.TiltPosition}
+	s.mu.Unlock()
+
+	// Non-blocking send to position change channel
+	select {
+	case PositionChangeChan <- event:
+		logger.Debug("Position change event sent", "actor", s.Name, "position", event.Position)
+	default:
+		logger.Warn("Position change channel is full, dropping event", "actor", s.Name, "position", event.Position)
+	}
 
-	return nil
+	// Status updates arrive in MQTT callbacks, and waiting for the broker
+	// there blocks message processing. publishState is therefore called
+	// asynchronously and serialized by publishMu.
+	go s.publishState()
+}
+
+// StateTopic returns the topic the actor state is published on
+func (s *ShadingActor) StateTopic() string {
+	return config.Get().MQTT.Topic + "/" + s.Name
+}
+
+// publishState publishes the current state to <mqtt.topic>/<device-name>
+// if it differs from the last published state.
+func (s *ShadingActor) publishState() {
+	// The state is read while holding publishMu, so the latest state always wins
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+
+	s.mu.Lock()
+	message := PositionMessage{
+		Position:     s.Position,
+		SlatPosition: s.TiltPosition,
+		Tilted:       s.Tilted,
+		State:        s.State,
+		Moving:       isMoving(s.State),
+		Locked:       len(s.locks) > 0,
+	}
+	if s.lastPublished != nil && *s.lastPublished == message {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPublished = &message
+	s.mu.Unlock()
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		logger.Error("Failed to marshal actor state", "actor", s.Name, "error", err)
+		return
+	}
+
+	logger.Debug("Publishing actor state", "actor", s.Name, "position", message.Position, "state", message.State)
+	mqtt.PublishAbsolute(s.StateTopic(), string(data), true)
+}
+
+func isMoving(state string) bool {
+	return state == "opening" || state == "closing"
 }
@@ -0,0 +1,126 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mqtt-home/shelly-commands/config"
+	"github.com/philipparndt/go-logger"
+	"github.com/philipparndt/mqtt-gateway/mqtt"
+)
+
+// coverStatus is a status update of a cover, fields that are not part of the
+// update are nil or empty
+type coverStatus struct {
+	State        string
+	Position     *int
+	SlatPosition *int
+	Source       string
+}
+
+func (status Status) coverStatus() coverStatus {
+	return coverStatus{
+		State:        status.State,
+		Position:     &status.CurrentPos,
+		SlatPosition: &status.SlatPos,
+		Source:       status.Source,
+	}
+}
+
+// transport translates the cover operations to the protocol of the device
+type transport interface {
+	// subscribe passes every status update of the device to the handler
+	subscribe(handle func(coverStatus))
+	// requestStatus asks the device to publish its current status
+	requestStatus()
+	// setPosition, setSlatPosition and stop send the command, the context
+	// aborts waiting for a response
+	setPosition(ctx context.Context, position int) error
+	setSlatPosition(ctx context.Context, position int) error
+	stop(ctx context.Context) error
+}
+
+func newTransport(device config.Device) transport {
+	interval := time.Duration(config.Get().Shelly.PollingInterval) * time.Second
+
+	switch device.Protocol {
+	case config.ProtocolHTTP:
+		return newHTTPTransport(device.Name, device.Address, device.Channel, interval)
+	case config.ProtocolGen1:
+		return &gen1Transport{name: device.Name, topicBase: device.TopicBase, channel: device.Channel}
+	case config.ProtocolRPC:
+		// Subscribe to the responses before the first request
+		getRPCClient()
+		t := &rpcTransport{gen2Transport: gen2Transport{name: device.Name, topicBase: device.TopicBase, channel: device.Channel}}
+		if device.Address != "" {
+			return &fallbackTransport{
+				transport: t,
+				http:      newHTTPTransport(device.Name, device.Address, device.Channel, interval),
+			}
+		}
+		return t
+	default:
+		return &gen2Transport{name: device.Name, topicBase: device.TopicBase, channel: device.Channel}
+	}
+}
+
+// gen2Transport uses the MQTT string commands of Gen2 devices
+// see:
+// https://shelly-api-docs.shelly.cloud/gen2/ComponentsAndServices/Cover#mqtt-control
+type gen2Transport struct {
+	name      string
+	topicBase string
+	channel   int
+}
+
+func (t *gen2Transport) commandTopic() string {
+	return fmt.Sprintf("%s/command/cover:%d", t.topicBase, t.channel)
+}
+
+func (t *gen2Transport) statusTopic() string {
+	return fmt.Sprintf("%s/status/cover:%d", t.topicBase, t.channel)
+}
+
+func (t *gen2Transport) subscribe(handle func(coverStatus)) {
+	mqtt.Subscribe(t.statusTopic(), func(topic string, payload []byte) {
+		logger.Debug("Received MQTT message", "topic", topic, "payload", string(payload))
+
+		status := &Status{}
+		err := json.Unmarshal(payload, status)
+		if err != nil {
+			logger.Error("Failed to parse status", "actor", t.name, "error", err)
+			return
+		}
+
+		handle(status.coverStatus())
+	})
+}
+
+func (t *gen2Transport) requestStatus() {
+	mqtt.PublishAbsolute(t.commandTopic(), "status_update", false)
+}
+
+func (t *gen2Transport) setPosition(ctx context.Context, position int) error {
+	switch position {
+	case 0:
+		mqtt.PublishAbsolute(t.commandTopic(), "close", false)
+	case 100:
+		mqtt.PublishAbsolute(t.commandTopic(), "open", false)
+	default:
+		mqtt.PublishAbsolute(t.commandTopic(), "pos,"+strconv.Itoa(position), false)
+	}
+	return nil
+}
+
+func (t *gen2Transport) setSlatPosition(ctx context.Context, position int) error {
+	mqtt.PublishAbsolute(t.commandTopic(), "slat_pos,"+strconv.Itoa(position), false)
+	return nil
+}
+
+func (t *gen2Transport) stop(ctx context.Context) error {
+	mqtt.PublishAbsolute(t.commandTopic(), "stop", false)
+	return nil
+}
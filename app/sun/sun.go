@@ -0,0 +1,147 @@
+package sun
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// see:
+// https://en.wikipedia.org/wiki/Sunrise_equation
+// https://gml.noaa.gov/grad/solcalc/calcdetails.html
+
+type Event string
+
+const (
+	Sunrise   Event = "sunrise"
+	Sunset    Event = "sunset"
+	CivilDawn Event = "civilDawn"
+	CivilDusk Event = "civilDusk"
+)
+
+var Events = []Event{CivilDawn, Sunrise, Sunset, CivilDusk}
+
+const (
+	julianUnixEpoch = 2440587.5
+	julian2000      = 2451545.0
+	obliquity       = 23.4397 * math.Pi / 180
+)
+
+// elevation returns the sun elevation of the event in degrees and whether
+// it is a morning event
+func (e Event) elevation() (float64, bool, error) {
+	switch e {
+	case Sunrise:
+		return -0.833, true, nil
+	case Sunset:
+		return -0.833, false, nil
+	case CivilDawn:
+		return -6, true, nil
+	case CivilDusk:
+		return -6, false, nil
+	}
+	return 0, false, fmt.Errorf("unknown sun event %q", e)
+}
+
+func ParseEvent(name string) (Event, error) {
+	event := Event(name)
+	if _, _, err := event.elevation(); err != nil {
+		return "", err
+	}
+	return event, nil
+}
+
+func toJulian(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + julianUnixEpoch
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(0, int64((j-julianUnixEpoch)*float64(24*time.Hour)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// Time returns the time of the event on the calendar date of the given time
+// (in its location). It returns false if the event does not happen on that
+// day, e.g. during polar day or night.
+func Time(date time.Time, latitude, longitude float64, event Event) (time.Time, bool) {
+	h0, morning, err := event.elevation()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Days since J2000 for the calendar date
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(toJulian(noon) - julian2000)
+
+	// Mean solar time, mean anomaly and equation of the center
+	jStar := n - longitude/360
+	m := math.Mod(357.5291+0.98560028*jStar, 360)
+	mRad := radians(m)
+	c := 1.9148*math.Sin(mRad) + 0.02*math.Sin(2*mRad) + 0.0003*math.Sin(3*mRad)
+
+	// Ecliptic longitude and solar transit
+	lambda := radians(math.Mod(m+c+180+102.9372, 360))
+	transit := julian2000 + jStar + 0.0053*math.Sin(mRad) - 0.0069*math.Sin(2*lambda)
+
+	// Declination and hour angle
+	sinDelta := math.Sin(lambda) * math.Sin(obliquity)
+	cosDelta := math.Cos(math.Asin(sinDelta))
+	phi := radians(latitude)
+	cosOmega := (math.Sin(radians(h0)) - math.Sin(phi)*sinDelta) / (math.Cos(phi) * cosDelta)
+	if cosOmega < -1 || cosOmega > 1 {
+		return time.Time{}, false
+	}
+	omega := degrees(math.Acos(cosOmega))
+
+	if morning {
+		return fromJulian(transit - omega/360).In(date.Location()), true
+	}
+	return fromJulian(transit + omega/360).In(date.Location()), true
+}
+
+// Next returns the first occurrence of the event after the given time.
+// The zero time is returned if the event does not happen within a year.
+func Next(after time.Time, latitude, longitude float64, event Event) time.Time {
+	for day := 0; day <= 366; day++ {
+		date := time.Date(after.Year(), after.Month(), after.Day()+day, 12, 0, 0, 0, after.Location())
+		t, ok := Time(date, latitude, longitude, event)
+		if ok && t.After(after) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Position returns the sun azimuth (degrees clockwise from north) and
+// elevation (degrees above the horizon) at the given time and location
+func Position(t time.Time, latitude, longitude float64) (azimuth float64, elevation float64) {
+	d := toJulian(t) - julian2000
+
+	// Mean anomaly, mean longitude and ecliptic longitude of the sun
+	g := radians(math.Mod(357.529+0.98560028*d, 360))
+	q := math.Mod(280.459+0.98564736*d, 360)
+	l := radians(q + 1.915*math.Sin(g) + 0.020*math.Sin(2*g))
+	e := radians(23.439 - 0.00000036*d)
+
+	// Right ascension and declination
+	ra := math.Atan2(math.Cos(e)*math.Sin(l), math.Cos(l))
+	delta := math.Asin(math.Sin(e) * math.Sin(l))
+
+	// Local hour angle from the Greenwich mean sidereal time
+	gmst := math.Mod(18.697374558+24.06570982441908*d, 24)
+	h := radians(gmst*15+longitude) - ra
+
+	phi := radians(latitude)
+	elevation = degrees(math.Asin(math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Cos(h)))
+	azimuth = degrees(math.Atan2(-math.Sin(h)*math.Cos(delta), math.Sin(delta)*math.Cos(phi)-math.Cos(delta)*math.Sin(phi)*math.Cos(h)))
+	azimuth = math.Mod(azimuth+360, 360)
+
+	return azimuth, elevation
+}
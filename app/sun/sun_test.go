@@ -0,0 +1,112 @@
+package sun
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const (
+	berlinLatitude  = 52.52
+	berlinLongitude = 13.405
+)
+
+func TestTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Published reference times for Berlin, rounded to the minute
+	tests := []struct {
+		name  string
+		date  time.Time
+		event Event
+		want  time.Time
+	}{
+		{"summer sunrise", time.Date(2025, 6, 21, 12, 0, 0, 0, berlin), Sunrise, time.Date(2025, 6, 21, 4, 43, 0, 0, berlin)},
+		{"summer sunset", time.Date(2025, 6, 21, 12, 0, 0, 0, berlin), Sunset, time.Date(2025, 6, 21, 21, 33, 0, 0, berlin)},
+		{"winter sunrise", time.Date(2025, 12, 21, 12, 0, 0, 0, berlin), Sunrise, time.Date(2025, 12, 21, 8, 15, 0, 0, berlin)},
+		{"winter sunset", time.Date(2025, 12, 21, 12, 0, 0, 0, berlin), Sunset, time.Date(2025, 12, 21, 15, 54, 0, 0, berlin)},
+		{"civil dawn before sunrise", time.Date(2025, 12, 21, 12, 0, 0, 0, berlin), CivilDawn, time.Date(2025, 12, 21, 7, 34, 0, 0, berlin)},
+		{"civil dusk after sunset", time.Date(2025, 12, 21, 12, 0, 0, 0, berlin), CivilDusk, time.Date(2025, 12, 21, 16, 35, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Time(tt.date, berlinLatitude, berlinLongitude, tt.event)
+			if !ok {
+				t.Fatalf("Time() reported no %s", tt.event)
+			}
+			if diff := got.Sub(tt.want); diff.Abs() > 2*time.Minute {
+				t.Errorf("Time() = %s, want %s (±2m)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimePolarNight(t *testing.T) {
+	// Tromsø has no sunrise around the winter solstice
+	date := time.Date(2025, 12, 21, 12, 0, 0, 0, time.UTC)
+	if got, ok := Time(date, 69.65, 18.96, Sunrise); ok {
+		t.Errorf("Time() = %s, want no sunrise", got)
+	}
+}
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After the sunset, the next sunrise is on the following day
+	after := time.Date(2025, 6, 21, 22, 0, 0, 0, berlin)
+	got := Next(after, berlinLatitude, berlinLongitude, Sunrise)
+	want := time.Date(2025, 6, 22, 4, 43, 0, 0, berlin)
+	if diff := got.Sub(want); diff.Abs() > 2*time.Minute {
+		t.Errorf("Next() = %s, want %s (±2m)", got, want)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	tests := []struct {
+		name          string
+		time          time.Time
+		wantAzimuth   float64
+		wantElevation float64
+	}{
+		// Solar noon at the summer solstice: 90° - latitude + 23.44°
+		{"summer solar noon", time.Date(2025, 6, 21, 11, 8, 0, 0, time.UTC), 180, 60.9},
+		// Solar noon at the winter solstice: 90° - latitude - 23.44°
+		{"winter solar noon", time.Date(2025, 12, 21, 11, 1, 0, 0, time.UTC), 180, 14.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azimuth, elevation := Position(tt.time, berlinLatitude, berlinLongitude)
+			if math.Abs(azimuth-tt.wantAzimuth) > 2 {
+				t.Errorf("azimuth = %.2f, want %.2f (±2)", azimuth, tt.wantAzimuth)
+			}
+			if math.Abs(elevation-tt.wantElevation) > 0.5 {
+				t.Errorf("elevation = %.2f, want %.2f (±0.5)", elevation, tt.wantElevation)
+			}
+		})
+	}
+
+	// The sun rises in the east and sets in the west
+	azimuth, _ := Position(time.Date(2025, 3, 20, 5, 30, 0, 0, time.UTC), berlinLatitude, berlinLongitude)
+	if azimuth < 80 || azimuth > 100 {
+		t.Errorf("equinox morning azimuth = %.2f, want about 90", azimuth)
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	for _, event := range Events {
+		if _, err := ParseEvent(string(event)); err != nil {
+			t.Errorf("ParseEvent(%q): %v", event, err)
+		}
+	}
+	if _, err := ParseEvent("noon"); err == nil {
+		t.Error("ParseEvent(\"noon\") succeeded, want error")
+	}
+}
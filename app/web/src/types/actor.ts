@@ -3,12 +3,40 @@ export interface ActorStatus {
   displayName: string;
   ip: string;
   serial: string;
+  channel: number;
   position: number;
   tilted: boolean;
   tiltPosition: number;
   deviceType: string;
   rank: number;
   groupId?: string; // Make groupId optional since it might not exist
+  locked: boolean;
+  locks?: ActorLock[];
+  maintenance: boolean;
+  manualOverride: boolean;
+  manualInteraction?: ManualInteraction;
+  hold?: ActorHold;
+  contactOpen?: boolean;
+}
+
+export interface ActorHold {
+  priority: "default" | "automation" | "manual" | "safety";
+  until: string;
+  reason: string;
+}
+
+export interface ManualInteraction {
+  source: string;
+  time: string;
+  until: string;
+}
+
+export interface ActorLock {
+  key: string;
+  reason: string;
+  since: string;
+  forceable?: boolean;
+  safety?: boolean;
 }
 
 export interface GroupInfo {
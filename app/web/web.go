@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
@@ -14,6 +15,10 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/mqtt-home/shelly-commands/commands"
+	"github.com/mqtt-home/shelly-commands/maintenance"
+	"github.com/mqtt-home/shelly-commands/protection"
+	"github.com/mqtt-home/shelly-commands/scene"
+	"github.com/mqtt-home/shelly-commands/schedule"
 	"github.com/mqtt-home/shelly-commands/shelly"
 	"github.com/philipparndt/go-logger"
 	loggerchi "github.com/philipparndt/go-logger/chi"
@@ -29,6 +34,10 @@ type SSEClient struct {
 
 type WebServer struct {
 	registry      *shelly.ActorRegistry
+	scenes        *scene.Manager
+	scheduler     *schedule.Scheduler
+	emergency     *protection.Emergency
+	maintenance   *maintenance.Manager
 	router        *chi.Mux
 	sseClients    map[string]*SSEClient
 	sseClients_mu sync.RWMutex
@@ -39,6 +48,7 @@ type ActorStatus struct {
 	DisplayName  string   `json:"displayName"`
 	IP           string   `json:"ip"`
 	Serial       string   `json:"serial"`
+	Channel      int      `json:"channel"`
 	Position     int      `json:"position"`
 	Tilted       bool     `json:"tilted"`
 	TiltPosition int      `json:"tiltPosition"`
@@ -46,22 +56,88 @@ type ActorStatus struct {
 	Rank         int      `json:"rank"`
 	GroupIDs     []string `json:"groupIds"`
 	// Deprecated: Use GroupIDs instead. Kept for backward compatibility.
-	GroupID string `json:"groupId"`
+	GroupID string        `json:"groupId"`
+	Locked  bool          `json:"locked"`
+	Locks   []shelly.Lock `json:"locks,omitempty"`
+	// Maintenance is set while the actor is locked for maintenance
+	Maintenance bool `json:"maintenance"`
+	// ManualOverride is set while schedules and automation back off after a manual interaction
+	ManualOverride    bool                      `json:"manualOverride"`
+	ManualInteraction *shelly.ManualInteraction `json:"manualInteraction,omitempty"`
+	// Hold rejects commands with a lower priority until it expires
+	Hold *shelly.Hold `json:"hold,omitempty"`
+	// ContactOpen is set while the window or door contact of the actor is open
+	ContactOpen bool `json:"contactOpen,omitempty"`
 }
 
 type TiltRequest struct {
-	Position int `json:"position"`
+	Position int  `json:"position"`
+	Force    bool `json:"force,omitempty"`
 }
 
 type SetPositionRequest struct {
-	Position int `json:"position"`
+	Position int  `json:"position"`
+	Force    bool `json:"force,omitempty"`
 }
 
-func NewWebServer(registry *shelly.ActorRegistry) *WebServer {
+type MoveRequest struct {
+	Position int  `json:"position"`
+	Slat     *int `json:"slat,omitempty"`
+	Force    bool `json:"force,omitempty"`
+}
+
+// validate checks the request, the slat is required if one of the actors is
+// a blind
+func (req MoveRequest) validate(actors []*shelly.ShadingActor) error {
+	if req.Position < 0 || req.Position > 100 {
+		return fmt.Errorf("Position must be between 0 and 100")
+	}
+	if req.Slat == nil {
+		for _, actor := range actors {
+			if !actor.IsRollerShutter() {
+				return fmt.Errorf("Slat is required for blinds")
+			}
+		}
+		return nil
+	}
+	if *req.Slat < 0 || *req.Slat > 100 {
+		return fmt.Errorf("Slat must be between 0 and 100")
+	}
+	return nil
+}
+
+func (req MoveRequest) slatText() string {
+	if req.Slat == nil {
+		return "unchanged"
+	}
+	return strconv.Itoa(*req.Slat)
+}
+
+// command returns the tilt command, or a set command for roller shutters
+// without slat
+func (req MoveRequest) command() commands.LLCommand {
+	command := commands.LLCommand{
+		Action:   commands.LLActionTilt,
+		Position: req.Position,
+		Slat:     req.Slat,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
+	}
+	if req.Slat == nil {
+		command.Action = commands.LLActionSet
+	}
+	return command
+}
+
+func NewWebServer(registry *shelly.ActorRegistry, scenes *scene.Manager, scheduler *schedule.Scheduler, emergency *protection.Emergency, locks *maintenance.Manager) *WebServer {
 	ws := &WebServer{
-		registry:   registry,
-		router:     chi.NewRouter(),
-		sseClients: make(map[string]*SSEClient),
+		registry:    registry,
+		scenes:      scenes,
+		scheduler:   scheduler,
+		emergency:   emergency,
+		maintenance: locks,
+		router:      chi.NewRouter(),
+		sseClients:  make(map[string]*SSEClient),
 	}
 	ws.setupRoutes()
 
@@ -93,13 +169,31 @@ func (ws *WebServer) setupRoutes() {
 		r.Post("/actors/{actorName}/position", ws.setActorPosition)
 		r.Post("/actors/{actorName}/tilt", ws.tiltActor)
 		r.Post("/actors/{actorName}/slat", ws.setSlatPosition)
+		r.Post("/actors/{actorName}/stop", ws.stopActor)
+		r.Post("/actors/{actorName}/move", ws.moveActor)
+		r.Delete("/actors/{actorName}/hold", ws.releaseHold)
+		r.Post("/actors/{actorName}/lock", ws.lockActor)
+		r.Delete("/actors/{actorName}/lock", ws.unlockActor)
 		r.Post("/actors/all/position", ws.setAllActorsPosition)
 		r.Post("/actors/all/tilt", ws.tiltAllActors)
 		r.Post("/actors/all/slat", ws.setSlatPositionAll)
+		r.Post("/actors/all/stop", ws.stopAllActors)
 		r.Get("/groups", ws.getAllGroups)
 		r.Post("/groups/{groupId}/position", ws.setGroupPosition)
 		r.Post("/groups/{groupId}/tilt", ws.tiltGroup)
 		r.Post("/groups/{groupId}/slat", ws.setSlatPositionGroup)
+		r.Post("/groups/{groupId}/stop", ws.stopGroup)
+		r.Post("/groups/{groupId}/move", ws.moveGroup)
+		r.Post("/groups/{groupId}/lock", ws.lockGroup)
+		r.Delete("/groups/{groupId}/lock", ws.unlockGroup)
+		r.Get("/scenes", ws.getAllScenes)
+		r.Post("/scenes/{sceneId}/activate", ws.activateScene)
+		r.Get("/schedules", ws.getAllSchedules)
+		r.Post("/schedules/{scheduleId}/enable", ws.enableSchedule)
+		r.Post("/schedules/{scheduleId}/disable", ws.disableSchedule)
+		r.Get("/sun", ws.getSunEvents)
+		r.Get("/emergency", ws.getEmergencies)
+		r.Post("/emergency/{triggerId}/clear", ws.clearEmergency)
 		r.Get("/events", ws.handleSSE)
 	})
 
@@ -128,30 +222,70 @@ func (ws *WebServer) healthCheck(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(health)
 }
 
+func newActorStatus(actor *shelly.ShadingActor) ActorStatus {
+	// Get current position
+	position, err := actor.GetPosition()
+	if err != nil {
+		logger.Error("Failed to get position for actor", "actor", actor.Name, "error", err)
+		position = actor.Position // fallback to cached position
+	}
+
+	locks := actor.Locks()
+	manual := actor.ManualInteraction()
+
+	return ActorStatus{
+		Name:         actor.Name,
+		DisplayName:  actor.DisplayName(),
+		IP:           actor.Endpoint(),
+		Serial:       actor.Serial,
+		Channel:      actor.Channel,
+		Position:     position,
+		Tilted:       actor.Tilted,
+		TiltPosition: actor.TiltPosition,
+		DeviceType:   string(actor.DeviceType),
+		Rank:         actor.Rank,
+		GroupIDs:     actor.GetGroupIDs(),
+		GroupID:      actor.GroupID, // Keep for backward compatibility
+		Locked:       len(locks) > 0,
+		Locks:        locks,
+		Maintenance:  maintenance.IsLocked(actor),
+
+		ManualOverride:    manual != nil && time.Now().Before(manual.Until),
+		ManualInteraction: manual,
+		Hold:              actor.Hold(),
+		ContactOpen:       actor.ContactOpen(),
+	}
+}
+
+// submitError reports a command that was not accepted by the actor
+func submitError(w http.ResponseWriter, err error) {
+	status := http.StatusConflict
+	if errors.Is(err, shelly.ErrLocked) {
+		status = http.StatusLocked
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// submitAll queues the command on all actors. It returns the number of
+// accepted commands and the names of the actors that rejected the command.
+func submitAll(actors []*shelly.ShadingActor, command commands.LLCommand) (int, []string) {
+	accepted := 0
+	rejected := []string{}
+	for _, actor := range actors {
+		if err := actor.Submit(command); err != nil {
+			rejected = append(rejected, actor.Name)
+			continue
+		}
+		accepted++
+	}
+	return accepted, rejected
+}
+
 func (ws *WebServer) getAllActors(w http.ResponseWriter, r *http.Request) {
 	var actors []ActorStatus
 
 	for _, actor := range ws.registry.Actors {
-		// Get current position
-		position, err := actor.GetPosition()
-		if err != nil {
-			logger.Error("Failed to get position for actor", "actor", actor.Name, "error", err)
-			position = actor.Position // fallback to cached position
-		}
-
-		status := ActorStatus{
-			Name:         actor.Name,
-			DisplayName:  actor.DisplayName(),
-			IP:           actor.TopicBase,
-			Serial:       actor.Serial,
-			Position:     position,
-			Tilted:       actor.Tilted,
-			TiltPosition: actor.TiltPosition,
-			DeviceType:   string(actor.DeviceType),
-			Rank:         actor.Rank,
-			GroupIDs:     actor.GetGroupIDs(),
-			GroupID:      actor.GroupID, // Keep for backward compatibility
-		}
+		status := newActorStatus(actor)
 		actors = append(actors, status)
 	}
 
@@ -176,26 +310,7 @@ func (ws *WebServer) getActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get current position
-	position, err := actor.GetPosition()
-	if err != nil {
-		logger.Error("Failed to get position for actor", actor.Name, err)
-		position = actor.Position // fallback to cached position
-	}
-
-	status := ActorStatus{
-		Name:         actor.Name,
-		DisplayName:  actor.DisplayName(),
-		IP:           actor.TopicBase,
-		Serial:       actor.Serial,
-		Position:     position,
-		Tilted:       actor.Tilted,
-		TiltPosition: actor.TiltPosition,
-		DeviceType:   string(actor.DeviceType),
-		Rank:         actor.Rank,
-		GroupIDs:     actor.GetGroupIDs(),
-		GroupID:      actor.GroupID, // Keep for backward compatibility
-	}
+	status := newActorStatus(actor)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
@@ -224,9 +339,14 @@ func (ws *WebServer) setActorPosition(w http.ResponseWriter, r *http.Request) {
 	command := commands.LLCommand{
 		Action:   commands.LLActionSet,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
-	go actor.Apply(command)
+	if err := actor.Submit(command); err != nil {
+		submitError(w, err)
+		return
+	}
 
 	logger.Info(fmt.Sprintf("Set position for actor %s to %d", actorName, req.Position))
 
@@ -263,9 +383,14 @@ func (ws *WebServer) tiltActor(w http.ResponseWriter, r *http.Request) {
 	command := commands.LLCommand{
 		Action:   commands.LLActionTilt,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
-	go actor.Apply(command)
+	if err := actor.Submit(command); err != nil {
+		submitError(w, err)
+		return
+	}
 
 	logger.Info(fmt.Sprintf("Tilt actor %s to position %d", actorName, req.Position))
 
@@ -294,13 +419,11 @@ func (ws *WebServer) tiltAllActors(w http.ResponseWriter, r *http.Request) {
 	command := commands.LLCommand{
 		Action:   commands.LLActionTilt,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
-	tiltedCount := 0
-	for _, actor := range ws.registry.Actors {
-		go actor.Apply(command)
-		tiltedCount++
-	}
+	tiltedCount, rejected := submitAll(ws.registry.GetAllActors(), command)
 
 	logger.Info(fmt.Sprintf("Tilt all %d actors to position %d", tiltedCount, req.Position))
 
@@ -312,8 +435,9 @@ func (ws *WebServer) tiltAllActors(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "success",
-		"count":  tiltedCount,
+		"status":   "success",
+		"count":    tiltedCount,
+		"rejected": rejected,
 	})
 }
 
@@ -340,9 +464,14 @@ func (ws *WebServer) setSlatPosition(w http.ResponseWriter, r *http.Request) {
 	command := commands.LLCommand{
 		Action:   commands.LLActionSlat,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
-	go actor.Apply(command)
+	if err := actor.Submit(command); err != nil {
+		submitError(w, err)
+		return
+	}
 
 	logger.Info(fmt.Sprintf("Set slat position for actor %s to %d", actorName, req.Position))
 
@@ -371,13 +500,11 @@ func (ws *WebServer) setSlatPositionAll(w http.ResponseWriter, r *http.Request)
 	command := commands.LLCommand{
 		Action:   commands.LLActionSlat,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
-	slatCount := 0
-	for _, actor := range ws.registry.Actors {
-		go actor.Apply(command)
-		slatCount++
-	}
+	slatCount, rejected := submitAll(ws.registry.GetAllActors(), command)
 
 	logger.Info(fmt.Sprintf("Set slat position for all %d actors to %d", slatCount, req.Position))
 
@@ -389,8 +516,113 @@ func (ws *WebServer) setSlatPositionAll(w http.ResponseWriter, r *http.Request)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "success",
-		"count":  slatCount,
+		"status":   "success",
+		"count":    slatCount,
+		"rejected": rejected,
+	})
+}
+
+func (ws *WebServer) moveActor(w http.ResponseWriter, r *http.Request) {
+	actorName := chi.URLParam(r, "actorName")
+	actor := ws.registry.GetActor(actorName)
+
+	if actor == nil {
+		http.Error(w, fmt.Sprintf("Actor '%s' not found", actorName), http.StatusNotFound)
+		return
+	}
+
+	var req MoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate([]*shelly.ShadingActor{actor}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := actor.Submit(req.command()); err != nil {
+		submitError(w, err)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Move actor %s to position %d with slat %s", actorName, req.Position, req.slatText()))
+
+	// Broadcast state change after a brief delay to allow the actor to update
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		ws.broadcastStateChange()
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+func (ws *WebServer) stopActor(w http.ResponseWriter, r *http.Request) {
+	actorName := chi.URLParam(r, "actorName")
+	actor := ws.registry.GetActor(actorName)
+
+	if actor == nil {
+		http.Error(w, fmt.Sprintf("Actor '%s' not found", actorName), http.StatusNotFound)
+		return
+	}
+
+	if err := actor.Submit(commands.LLCommand{Action: commands.LLActionStop, Priority: commands.PriorityManual}); err != nil {
+		submitError(w, err)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Stop actor %s", actorName))
+
+	// Broadcast state change after a brief delay to allow the actor to update
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		ws.broadcastStateChange()
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+func (ws *WebServer) releaseHold(w http.ResponseWriter, r *http.Request) {
+	actorName := chi.URLParam(r, "actorName")
+	actor := ws.registry.GetActor(actorName)
+
+	if actor == nil {
+		http.Error(w, fmt.Sprintf("Actor '%s' not found", actorName), http.StatusNotFound)
+		return
+	}
+
+	released := actor.ReleaseHold()
+
+	logger.Info(fmt.Sprintf("Release hold of actor %s", actorName))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"released": released,
+	})
+}
+
+func (ws *WebServer) stopAllActors(w http.ResponseWriter, r *http.Request) {
+	command := commands.LLCommand{Action: commands.LLActionStop, Priority: commands.PriorityManual}
+
+	stoppedCount, rejected := submitAll(ws.registry.GetAllActors(), command)
+
+	logger.Info(fmt.Sprintf("Stop all %d actors", stoppedCount))
+
+	// Broadcast state change after a brief delay to allow the actors to update
+	go func() {
+		time.Sleep(1 * time.Second)
+		ws.broadcastStateChange()
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"count":    stoppedCount,
+		"rejected": rejected,
 	})
 }
 
@@ -409,13 +641,11 @@ func (ws *WebServer) setAllActorsPosition(w http.ResponseWriter, r *http.Request
 	command := commands.LLCommand{
 		Action:   commands.LLActionSet,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
-	affectedCount := 0
-	for _, actor := range ws.registry.Actors {
-		go actor.Apply(command)
-		affectedCount++
-	}
+	affectedCount, rejected := submitAll(ws.registry.GetAllActors(), command)
 
 	logger.Info(fmt.Sprintf("Set position for all %d actors to %d", affectedCount, req.Position))
 
@@ -427,8 +657,9 @@ func (ws *WebServer) setAllActorsPosition(w http.ResponseWriter, r *http.Request
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "success",
-		"count":  affectedCount,
+		"status":   "success",
+		"count":    affectedCount,
+		"rejected": rejected,
 	})
 }
 
@@ -450,26 +681,7 @@ func (ws *WebServer) getAllGroups(w http.ResponseWriter, r *http.Request) {
 		actorStatuses := make([]ActorStatus, 0, len(actors))
 
 		for _, actor := range actors {
-			// Get current position
-			position, err := actor.GetPosition()
-			if err != nil {
-				logger.Error("Failed to get position for actor", "actor", actor.Name, "error", err)
-				position = actor.Position // fallback to cached position
-			}
-
-			status := ActorStatus{
-				Name:         actor.Name,
-				DisplayName:  actor.DisplayName(),
-				IP:           actor.TopicBase,
-				Serial:       actor.Serial,
-				Position:     position,
-				Tilted:       actor.Tilted,
-				TiltPosition: actor.TiltPosition,
-				DeviceType:   string(actor.DeviceType),
-				Rank:         actor.Rank,
-				GroupIDs:     actor.GetGroupIDs(),
-				GroupID:      actor.GroupID, // Keep for backward compatibility
-			}
+			status := newActorStatus(actor)
 			actorStatuses = append(actorStatuses, status)
 		}
 
@@ -521,6 +733,8 @@ func (ws *WebServer) setGroupPosition(w http.ResponseWriter, r *http.Request) {
 	command := commands.LLCommand{
 		Action:   commands.LLActionSet,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
 	// Use the registry's GetActorsByGroup method
@@ -531,9 +745,7 @@ func (ws *WebServer) setGroupPosition(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, actor := range groupActors {
-		go actor.Apply(command)
-	}
+	accepted, rejected := submitAll(groupActors, command)
 
 	logger.Info(fmt.Sprintf("Set position for %d actors in group %s to %d", len(groupActors), groupID, req.Position))
 
@@ -545,9 +757,10 @@ func (ws *WebServer) setGroupPosition(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "success",
-		"count":  len(groupActors),
-		"group":  groupID,
+		"status":   "success",
+		"count":    accepted,
+		"rejected": rejected,
+		"group":    groupID,
 	})
 }
 
@@ -568,6 +781,8 @@ func (ws *WebServer) tiltGroup(w http.ResponseWriter, r *http.Request) {
 	command := commands.LLCommand{
 		Action:   commands.LLActionTilt,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
 	// Use the registry's GetActorsByGroup method
@@ -578,9 +793,7 @@ func (ws *WebServer) tiltGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, actor := range groupActors {
-		go actor.Apply(command)
-	}
+	accepted, rejected := submitAll(groupActors, command)
 
 	logger.Info(fmt.Sprintf("Tilt %d actors in group %s to position %d", len(groupActors), groupID, req.Position))
 
@@ -592,9 +805,10 @@ func (ws *WebServer) tiltGroup(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "success",
-		"count":  len(groupActors),
-		"group":  groupID,
+		"status":   "success",
+		"count":    accepted,
+		"rejected": rejected,
+		"group":    groupID,
 	})
 }
 
@@ -615,6 +829,8 @@ func (ws *WebServer) setSlatPositionGroup(w http.ResponseWriter, r *http.Request
 	command := commands.LLCommand{
 		Action:   commands.LLActionSlat,
 		Position: req.Position,
+		Force:    req.Force,
+		Priority: commands.PriorityManual,
 	}
 
 	// Use the registry's GetActorsByGroup method
@@ -625,9 +841,7 @@ func (ws *WebServer) setSlatPositionGroup(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	for _, actor := range groupActors {
-		go actor.Apply(command)
-	}
+	accepted, rejected := submitAll(groupActors, command)
 
 	logger.Info(fmt.Sprintf("Set slat position for %d actors in group %s to %d", len(groupActors), groupID, req.Position))
 
@@ -639,9 +853,247 @@ func (ws *WebServer) setSlatPositionGroup(w http.ResponseWriter, r *http.Request
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"count":    accepted,
+		"rejected": rejected,
+		"group":    groupID,
+	})
+}
+
+func (ws *WebServer) moveGroup(w http.ResponseWriter, r *http.Request) {
+	groupID := chi.URLParam(r, "groupId")
+
+	var req MoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Use the registry's GetActorsByGroup method
+	groupActors := ws.registry.GetActorsByGroup(groupID)
+
+	if len(groupActors) == 0 {
+		http.Error(w, fmt.Sprintf("No actors found in group '%s'", groupID), http.StatusNotFound)
+		return
+	}
+
+	if err := req.validate(groupActors); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accepted, rejected := submitAll(groupActors, req.command())
+
+	logger.Info(fmt.Sprintf("Move %d actors in group %s to position %d with slat %s", len(groupActors), groupID, req.Position, req.slatText()))
+
+	// Broadcast state change after a brief delay to allow the actors to update
+	go func() {
+		time.Sleep(1 * time.Second)
+		ws.broadcastStateChange()
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"count":    accepted,
+		"rejected": rejected,
+		"group":    groupID,
+	})
+}
+
+func (ws *WebServer) stopGroup(w http.ResponseWriter, r *http.Request) {
+	groupID := chi.URLParam(r, "groupId")
+
+	// Use the registry's GetActorsByGroup method
+	groupActors := ws.registry.GetActorsByGroup(groupID)
+
+	if len(groupActors) == 0 {
+		http.Error(w, fmt.Sprintf("No actors found in group '%s'", groupID), http.StatusNotFound)
+		return
+	}
+
+	command := commands.LLCommand{Action: commands.LLActionStop, Priority: commands.PriorityManual}
+	accepted, rejected := submitAll(groupActors, command)
+
+	logger.Info(fmt.Sprintf("Stop %d actors in group %s", len(groupActors), groupID))
+
+	// Broadcast state change after a brief delay to allow the actors to update
+	go func() {
+		time.Sleep(1 * time.Second)
+		ws.broadcastStateChange()
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"count":    accepted,
+		"rejected": rejected,
+		"group":    groupID,
+	})
+}
+
+func (ws *WebServer) lockActor(w http.ResponseWriter, r *http.Request) {
+	ws.setLocked(w, r, chi.URLParam(r, "actorName"), true)
+}
+
+func (ws *WebServer) unlockActor(w http.ResponseWriter, r *http.Request) {
+	ws.setLocked(w, r, chi.URLParam(r, "actorName"), false)
+}
+
+func (ws *WebServer) lockGroup(w http.ResponseWriter, r *http.Request) {
+	ws.setLocked(w, r, shelly.GroupPrefix+chi.URLParam(r, "groupId"), true)
+}
+
+func (ws *WebServer) unlockGroup(w http.ResponseWriter, r *http.Request) {
+	ws.setLocked(w, r, shelly.GroupPrefix+chi.URLParam(r, "groupId"), false)
+}
+
+// setLocked locks or unlocks an actor or group for maintenance. The request
+// body with a reason is optional.
+func (ws *WebServer) setLocked(w http.ResponseWriter, r *http.Request, target string, locked bool) {
+	var req maintenance.Request
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if _, err := ws.registry.Resolve(target); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var actors []string
+	var err error
+	if locked {
+		actors, err = ws.maintenance.Lock(target, req.Reason)
+	} else {
+		actors, err = ws.maintenance.Unlock(target)
+	}
+	go ws.broadcastStateChange()
+
+	if err != nil {
+		// The actors are (un)locked, but the state would be lost on restart
+		logger.Error("Failed to persist maintenance lock", "target", target, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to persist maintenance lock: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Set maintenance lock of %s to %t", target, locked))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"locked": locked,
+		"actors": actors,
+	})
+}
+
+func (ws *WebServer) getAllScenes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.scenes.List())
+}
+
+func (ws *WebServer) activateScene(w http.ResponseWriter, r *http.Request) {
+	sceneID := chi.URLParam(r, "sceneId")
+
+	if ws.scenes.Get(sceneID) == nil {
+		http.Error(w, fmt.Sprintf("Scene '%s' not found", sceneID), http.StatusNotFound)
+		return
+	}
+
+	if err := ws.scenes.Activate(sceneID, commands.PriorityManual); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Activated scene %s", sceneID))
+
+	// Broadcast state change after a brief delay to allow the actors to update
+	go func() {
+		time.Sleep(1 * time.Second)
+		ws.broadcastStateChange()
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
 		"status": "success",
-		"count":  len(groupActors),
-		"group":  groupID,
+		"scene":  sceneID,
+	})
+}
+
+func (ws *WebServer) getAllSchedules(w http.ResponseWriter, r *http.Request) {
+	runs := 5
+	if value := r.URL.Query().Get("runs"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > 100 {
+			http.Error(w, "runs must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+		runs = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.scheduler.List(runs))
+}
+
+func (ws *WebServer) enableSchedule(w http.ResponseWriter, r *http.Request) {
+	ws.setScheduleEnabled(w, r, true)
+}
+
+func (ws *WebServer) disableSchedule(w http.ResponseWriter, r *http.Request) {
+	ws.setScheduleEnabled(w, r, false)
+}
+
+func (ws *WebServer) setScheduleEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	scheduleID := chi.URLParam(r, "scheduleId")
+
+	if err := ws.scheduler.SetEnabled(scheduleID, enabled); err != nil {
+		http.Error(w, fmt.Sprintf("Schedule '%s' not found", scheduleID), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"schedule": scheduleID,
+		"enabled":  enabled,
+	})
+}
+
+func (ws *WebServer) getSunEvents(w http.ResponseWriter, r *http.Request) {
+	info := ws.scheduler.SunEvents(time.Now())
+	if info == nil {
+		http.Error(w, "No location configured", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+func (ws *WebServer) getEmergencies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.emergency.List())
+}
+
+func (ws *WebServer) clearEmergency(w http.ResponseWriter, r *http.Request) {
+	triggerID := chi.URLParam(r, "triggerId")
+
+	if err := ws.emergency.Clear(triggerID); err != nil {
+		http.Error(w, fmt.Sprintf("Safety trigger '%s' not found", triggerID), http.StatusNotFound)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Cleared emergency %s", triggerID))
+
+	go ws.broadcastStateChange()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"trigger": triggerID,
 	})
 }
 
@@ -775,26 +1227,7 @@ func (ws *WebServer) getAllActorsState() []ActorStatus {
 	var actorsState []ActorStatus
 
 	for _, actor := range ws.registry.Actors {
-		// Get current position
-		position, err := actor.GetPosition()
-		if err != nil {
-			logger.Error("Failed to get position for actor", "actor", actor.Name, "error", err)
-			position = actor.Position // fallback to cached position
-		}
-
-		state := ActorStatus{
-			Name:         actor.Name,
-			DisplayName:  actor.DisplayName(),
-			IP:           actor.TopicBase,
-			Serial:       actor.Serial,
-			Position:     position,
-			Tilted:       actor.Tilted,
-			TiltPosition: actor.TiltPosition,
-			DeviceType:   string(actor.DeviceType),
-			Rank:         actor.Rank,
-			GroupIDs:     actor.GetGroupIDs(),
-			GroupID:      actor.GroupID, // Keep for backward compatibility
-		}
+		state := newActorStatus(actor)
 		actorsState = append(actorsState, state)
 	}
 